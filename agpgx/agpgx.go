@@ -0,0 +1,372 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package agpgx registers the types of AgensGraph to github.com/jackc/pgx/v5.
+
+Once registered, graphid, _graphid, vertex, _vertex, edge, _edge and graphpath
+can be scanned into the types of package ag, including entities and paths
+defined by users, and the values of those types can be passed as parameters.
+graphid and _graphid are moved in binary format; the others are moved in text
+format.
+
+	conn, _ := pgx.Connect(ctx, "")
+	err := agpgx.Register(ctx, conn)
+
+With pgxpool, Register can be used as AfterConnect.
+
+	config, _ := pgxpool.ParseConfig("")
+	config.AfterConnect = agpgx.Register
+*/
+package agpgx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// TypeNames is the names of the types registered by RegisterTypes.
+var TypeNames = []string{
+	"graphid", "_graphid",
+	"vertex", "_vertex",
+	"edge", "_edge",
+	"graphpath",
+}
+
+// OIDs maps the name of a type to its OID.
+type OIDs map[string]uint32
+
+const oidsQuery = `SELECT typname, oid FROM pg_catalog.pg_type
+WHERE typnamespace = 'pg_catalog'::regnamespace AND typname = ANY($1)`
+
+// LoadOIDs looks up the OIDs of the types in TypeNames from conn.
+func LoadOIDs(ctx context.Context, conn *pgx.Conn) (OIDs, error) {
+	rows, err := conn.Query(ctx, oidsQuery, TypeNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	oids := OIDs{}
+	for rows.Next() {
+		var name string
+		var oid uint32
+		err = rows.Scan(&name, &oid)
+		if err != nil {
+			return nil, err
+		}
+		oids[name] = oid
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return oids, nil
+}
+
+// Register looks up the OIDs of AgensGraph types from conn and registers them
+// to the type map of conn. It can be used as AfterConnect of pgxpool.Config.
+func Register(ctx context.Context, conn *pgx.Conn) error {
+	oids, err := LoadOIDs(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("failed to load OIDs of AgensGraph types: %s", err)
+	}
+	return RegisterTypes(conn.TypeMap(), oids)
+}
+
+// RegisterTypes registers the types in TypeNames to m with oids. It returns an
+// error if the OID of any of the types is missing.
+func RegisterTypes(m *pgtype.Map, oids OIDs) error {
+	for _, name := range TypeNames {
+		oid, ok := oids[name]
+		if !ok {
+			return fmt.Errorf("OID of %s not found", name)
+		}
+		m.RegisterType(&pgtype.Type{Name: name, OID: oid, Codec: codecs[name]})
+	}
+
+	m.RegisterDefaultPgType(ag.GraphId{}, "graphid")
+	m.RegisterDefaultPgType([]ag.GraphId{}, "_graphid")
+	m.RegisterDefaultPgType(ag.BasicVertex{}, "vertex")
+	m.RegisterDefaultPgType([]ag.BasicVertex{}, "_vertex")
+	m.RegisterDefaultPgType(ag.BasicEdge{}, "edge")
+	m.RegisterDefaultPgType([]ag.BasicEdge{}, "_edge")
+	m.RegisterDefaultPgType(ag.BasicPath{}, "graphpath")
+
+	return nil
+}
+
+var codecs = map[string]pgtype.Codec{
+	"graphid": &codec{kind: kindScalar, decode: func(src []byte) (interface{}, error) {
+		var gid ag.GraphId
+		err := gid.Scan(src)
+		return gid, err
+	}, binary: graphIdBinary},
+	"_graphid": &codec{kind: kindArray, decode: func(src []byte) (interface{}, error) {
+		var gids []ag.GraphId
+		err := ag.Array(&gids).Scan(src)
+		return gids, err
+	}, binary: graphIdArrayBinary},
+	"vertex": &codec{kind: kindEntity, decode: func(src []byte) (interface{}, error) {
+		var v ag.BasicVertex
+		err := v.Scan(src)
+		return v, err
+	}},
+	"_vertex": &codec{kind: kindArray, decode: func(src []byte) (interface{}, error) {
+		var vs []ag.BasicVertex
+		err := ag.Array(&vs).Scan(src)
+		return vs, err
+	}},
+	"edge": &codec{kind: kindEntity, decode: func(src []byte) (interface{}, error) {
+		var e ag.BasicEdge
+		err := e.Scan(src)
+		return e, err
+	}},
+	"_edge": &codec{kind: kindArray, decode: func(src []byte) (interface{}, error) {
+		var es []ag.BasicEdge
+		err := ag.Array(&es).Scan(src)
+		return es, err
+	}},
+	"graphpath": &codec{kind: kindPath, decode: func(src []byte) (interface{}, error) {
+		var p ag.BasicPath
+		err := p.Scan(src)
+		return p, err
+	}},
+}
+
+type codecKind int
+
+const (
+	kindScalar codecKind = iota
+	kindArray
+	kindEntity
+	kindPath
+)
+
+// codec is pgtype.Codec for a type of AgensGraph. In text format, it
+// delegates to the Scanner and Valuer implementations of package ag.
+type codec struct {
+	kind   codecKind
+	decode func(src []byte) (interface{}, error)
+
+	// binary is nil if the type is moved in text format only
+	binary *binaryFormat
+}
+
+func (c *codec) FormatSupported(format int16) bool {
+	return format == pgtype.TextFormatCode || (format == pgtype.BinaryFormatCode && c.binary != nil)
+}
+
+func (c *codec) PreferredFormat() int16 {
+	if c.binary != nil {
+		return pgtype.BinaryFormatCode
+	}
+	return pgtype.TextFormatCode
+}
+
+func (c *codec) PlanEncode(m *pgtype.Map, oid uint32, format int16, value interface{}) pgtype.EncodePlan {
+	if format == pgtype.BinaryFormatCode && c.binary != nil {
+		// pgx falls back to text format for the other values
+		return c.binary.planEncode(m, value)
+	}
+	if format != pgtype.TextFormatCode {
+		return nil
+	}
+
+	switch value.(type) {
+	case driver.Valuer:
+		return encodePlanValuer{}
+	case []byte:
+		return encodePlanText{}
+	}
+
+	if c.kind == kindArray && value != nil && reflect.TypeOf(value).Kind() == reflect.Slice {
+		return encodePlanArray{}
+	}
+
+	return nil
+}
+
+type encodePlanValuer struct{}
+
+func (encodePlanValuer) Encode(value interface{}, buf []byte) ([]byte, error) {
+	v, err := value.(driver.Valuer).Value()
+	if err != nil {
+		return nil, err
+	}
+	return appendDriverValue(buf, v)
+}
+
+type encodePlanArray struct{}
+
+func (encodePlanArray) Encode(value interface{}, buf []byte) ([]byte, error) {
+	v, err := ag.Array(value).Value()
+	if err != nil {
+		return nil, err
+	}
+	return appendDriverValue(buf, v)
+}
+
+type encodePlanText struct{}
+
+func (encodePlanText) Encode(value interface{}, buf []byte) ([]byte, error) {
+	b := value.([]byte)
+	if b == nil {
+		return nil, nil
+	}
+	return append(buf, b...), nil
+}
+
+func appendDriverValue(buf []byte, v driver.Value) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return append(buf, v...), nil
+	case string:
+		return append(buf, v...), nil
+	default:
+		return nil, fmt.Errorf("invalid driver value: %T", v)
+	}
+}
+
+func (c *codec) PlanScan(m *pgtype.Map, oid uint32, format int16, target interface{}) pgtype.ScanPlan {
+	if format == pgtype.BinaryFormatCode && c.binary != nil {
+		if plan := c.binary.planScan(target); plan != nil {
+			return plan
+		}
+		// scan the text representation for the other targets
+		if plan := c.planScanText(target); plan != nil {
+			return scanPlanBinaryAsText{c.binary.text, plan}
+		}
+		return nil
+	}
+	if format != pgtype.TextFormatCode {
+		return nil
+	}
+
+	return c.planScanText(target)
+}
+
+func (c *codec) planScanText(target interface{}) pgtype.ScanPlan {
+
+	if _, ok := target.(sql.Scanner); ok {
+		return scanPlanScanner{}
+	}
+
+	switch c.kind {
+	case kindArray:
+		if rt := reflect.TypeOf(target); rt.Kind() == reflect.Ptr {
+			if k := rt.Elem().Kind(); k == reflect.Slice || k == reflect.Array {
+				return scanPlanArray{}
+			}
+		}
+	case kindEntity:
+		if _, ok := target.(ag.Entity); ok {
+			return scanPlanEntity{}
+		}
+	case kindPath:
+		if _, ok := target.(ag.PathSaver); ok {
+			return scanPlanPath{}
+		}
+	}
+
+	return nil
+}
+
+// scanSource returns nil interface for NULL.
+func scanSource(src []byte) interface{} {
+	if src == nil {
+		return nil
+	}
+	return src
+}
+
+type scanPlanBinaryAsText struct {
+	text func(src []byte) ([]byte, error)
+	next pgtype.ScanPlan
+}
+
+func (p scanPlanBinaryAsText) Scan(src []byte, target interface{}) error {
+	if src == nil {
+		return p.next.Scan(nil, target)
+	}
+	b, err := p.text(src)
+	if err != nil {
+		return err
+	}
+	return p.next.Scan(b, target)
+}
+
+type scanPlanScanner struct{}
+
+func (scanPlanScanner) Scan(src []byte, target interface{}) error {
+	return target.(sql.Scanner).Scan(scanSource(src))
+}
+
+type scanPlanArray struct{}
+
+func (scanPlanArray) Scan(src []byte, target interface{}) error {
+	return ag.Array(target).Scan(scanSource(src))
+}
+
+type scanPlanEntity struct{}
+
+func (scanPlanEntity) Scan(src []byte, target interface{}) error {
+	return ag.ScanEntity(scanSource(src), target.(ag.Entity))
+}
+
+type scanPlanPath struct{}
+
+func (scanPlanPath) Scan(src []byte, target interface{}) error {
+	return ag.ScanPath(scanSource(src), target.(ag.PathSaver))
+}
+
+func (c *codec) DecodeDatabaseSQLValue(m *pgtype.Map, oid uint32, format int16, src []byte) (driver.Value, error) {
+	if src == nil {
+		return nil, nil
+	}
+	// database/sql gets the text representation the Scanners of package ag
+	// expect
+	if format == pgtype.BinaryFormatCode && c.binary != nil {
+		return c.binary.text(src)
+	}
+	if format != pgtype.TextFormatCode {
+		return nil, fmt.Errorf("unknown format code: %d", format)
+	}
+	return append([]byte(nil), src...), nil
+}
+
+func (c *codec) DecodeValue(m *pgtype.Map, oid uint32, format int16, src []byte) (interface{}, error) {
+	if src == nil {
+		return nil, nil
+	}
+	if format == pgtype.BinaryFormatCode && c.binary != nil {
+		return c.binary.decode(src)
+	}
+	if format != pgtype.TextFormatCode {
+		return nil, fmt.Errorf("unknown format code: %d", format)
+	}
+	return c.decode(src)
+}
@@ -0,0 +1,409 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agpgx
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var agTestServer = flag.Bool("ag.test.server", false, "Run server tests")
+
+// OIDs for tests without a server
+var testOIDs = OIDs{
+	"graphid":   7002,
+	"_graphid":  7001,
+	"vertex":    7012,
+	"_vertex":   7011,
+	"edge":      7022,
+	"_edge":     7021,
+	"graphpath": 7032,
+}
+
+func newTestMap(t *testing.T) *pgtype.Map {
+	m := pgtype.NewMap()
+	err := RegisterTypes(m, testOIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestRegisterTypesError(t *testing.T) {
+	err := RegisterTypes(pgtype.NewMap(), OIDs{"graphid": 7002})
+	if err == nil {
+		t.Error("error expected for missing OIDs")
+	}
+}
+
+func TestScanGraphId(t *testing.T) {
+	m := newTestMap(t)
+
+	var gid ag.GraphId
+	err := m.Scan(testOIDs["graphid"], pgtype.TextFormatCode, []byte("3.14"), &gid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := gid.String(); s != "3.14" {
+		t.Errorf("got %s, want 3.14", s)
+	}
+
+	err = m.Scan(testOIDs["graphid"], pgtype.TextFormatCode, nil, &gid)
+	if err != nil {
+		t.Error(err)
+	} else if gid.Valid {
+		t.Errorf("got %s, want NULL", gid)
+	}
+
+	var gids []ag.GraphId
+	err = m.Scan(testOIDs["_graphid"], pgtype.TextFormatCode, []byte("{1.1,NULL}"), &gids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gids) != 2 || gids[0].String() != "1.1" || gids[1].Valid {
+		t.Errorf("got %v, want [1.1 NULL]", gids)
+	}
+}
+
+type testVertex struct {
+	ag.VertexHeader
+	Name string `ag:"name"`
+}
+
+func TestScanEntity(t *testing.T) {
+	m := newTestMap(t)
+
+	var v ag.BasicVertex
+	err := m.Scan(testOIDs["vertex"], pgtype.TextFormatCode, []byte(`v[3.1]{"name": "go"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Valid || v.Properties["name"] != "go" {
+		t.Errorf("got %s", v)
+	}
+
+	var u testVertex
+	err = m.Scan(testOIDs["vertex"], pgtype.TextFormatCode, []byte(`v[3.1]{"name": "go"}`), &u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !u.Valid || u.Name != "go" {
+		t.Errorf("got %+v", u)
+	}
+
+	var vs []ag.BasicVertex
+	err = m.Scan(testOIDs["_vertex"], pgtype.TextFormatCode, []byte(`[v[3.1]{"name": "a"},v[3.2]{"name": "b"}]`), &vs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 2 || vs[1].Properties["name"] != "b" {
+		t.Errorf("got %v", vs)
+	}
+
+	var e ag.BasicEdge
+	err = m.Scan(testOIDs["edge"], pgtype.TextFormatCode, []byte(`e[4.1][3.1,3.2]{}`), &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Valid || e.Start.String() != "3.1" {
+		t.Errorf("got %s", e)
+	}
+
+	var es []ag.BasicEdge
+	err = m.Scan(testOIDs["_edge"], pgtype.TextFormatCode, nil, &es)
+	if err != nil {
+		t.Error(err)
+	} else if es != nil {
+		t.Errorf("got %v, want nil", es)
+	}
+}
+
+func TestScanPath(t *testing.T) {
+	m := newTestMap(t)
+
+	src := []byte(`[v[3.1]{},e[4.1][3.1,3.2]{},v[3.2]{}]`)
+	var p ag.BasicPath
+	err := m.Scan(testOIDs["graphpath"], pgtype.TextFormatCode, src, &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Vertices) != 2 || len(p.Edges) != 1 {
+		t.Errorf("got %s", p)
+	}
+
+	var tp ag.TypedPath[testVertex, *testVertex, ag.BasicEdge, *ag.BasicEdge]
+	err = m.Scan(testOIDs["graphpath"], pgtype.TextFormatCode, src, &tp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tp.Vertices) != 2 || len(tp.Edges) != 1 {
+		t.Errorf("got %s", tp)
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	m := newTestMap(t)
+
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"graphid", "3.1"},
+		{"_graphid", "{3.1}"},
+		{"vertex", "v[3.1]{}"},
+		{"_vertex", "[v[3.1]{}]"},
+		{"edge", "e[4.1][3.1,3.2]{}"},
+		{"_edge", "[e[4.1][3.1,3.2]{}]"},
+		{"graphpath", "[v[3.1]{}]"},
+	}
+	for _, c := range tests {
+		oid := testOIDs[c.name]
+		typ, _ := m.TypeForOID(oid)
+		v, err := typ.Codec.DecodeValue(m, oid, pgtype.TextFormatCode, []byte(c.src))
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+
+		var ok bool
+		switch c.name {
+		case "graphid":
+			_, ok = v.(ag.GraphId)
+		case "_graphid":
+			_, ok = v.([]ag.GraphId)
+		case "vertex":
+			_, ok = v.(ag.BasicVertex)
+		case "_vertex":
+			_, ok = v.([]ag.BasicVertex)
+		case "edge":
+			_, ok = v.(ag.BasicEdge)
+		case "_edge":
+			_, ok = v.([]ag.BasicEdge)
+		case "graphpath":
+			_, ok = v.(ag.BasicPath)
+		}
+		if !ok {
+			t.Errorf("%s: got %T", c.name, v)
+		}
+
+		sv, err := typ.Codec.DecodeDatabaseSQLValue(m, oid, pgtype.TextFormatCode, []byte(c.src))
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+		} else if b, ok := sv.([]byte); !ok || string(b) != c.src {
+			t.Errorf("%s: got %v, want %s", c.name, sv, c.src)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	m := newTestMap(t)
+
+	gid, _ := ag.NewGraphId("3.14")
+	var v ag.BasicVertex
+	_ = v.Scan([]byte(`v[3.1]{"a": 1}`))
+
+	tests := []struct {
+		name  string
+		value interface{}
+		text  string
+	}{
+		{"graphid", gid, "3.14"},
+		{"_graphid", []ag.GraphId{gid}, "{3.14}"},
+		{"_graphid", ag.Array([]ag.GraphId{gid}), "{3.14}"},
+		{"vertex", v, `v[3.1]{"a":1}`},
+		{"_vertex", []ag.BasicVertex{v}, `[v[3.1]{"a":1}]`},
+		{"_vertex", []testVertex{{v.VertexHeader, "go"}}, `[v[3.1]{"name":"go"}]`},
+	}
+	for _, c := range tests {
+		b, err := m.Encode(testOIDs[c.name], pgtype.TextFormatCode, c.value, nil)
+		if err != nil {
+			t.Errorf("%s: %s", c.name, err)
+		} else if string(b) != c.text {
+			t.Errorf("%s: got %s, want %s", c.name, b, c.text)
+		}
+	}
+
+	b, err := m.Encode(testOIDs["graphid"], pgtype.TextFormatCode, ag.GraphId{}, nil)
+	if err != nil {
+		t.Error(err)
+	} else if b != nil {
+		t.Errorf("got %s, want NULL", b)
+	}
+}
+
+// fixtures in the wire format of graphid_send and array_send
+const (
+	graphIdBinaryHex      = "000300000000000e" // 3.14
+	graphIdArrayBinaryHex = "00000001" + "00000001" + "00001b5a" + "00000002" + "00000001" +
+		"00000008" + "000300000000000e" + "ffffffff" // {3.14,NULL}
+)
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestPreferredFormat(t *testing.T) {
+	m := newTestMap(t)
+	for _, name := range TypeNames {
+		want := int16(pgtype.TextFormatCode)
+		if name == "graphid" || name == "_graphid" {
+			want = pgtype.BinaryFormatCode
+		}
+		if f := m.FormatCodeForOID(testOIDs[name]); f != want {
+			t.Errorf("%s: got %d, want %d", name, f, want)
+		}
+	}
+}
+
+func TestScanGraphIdBinary(t *testing.T) {
+	m := newTestMap(t)
+
+	var gid ag.GraphId
+	err := m.Scan(testOIDs["graphid"], pgtype.BinaryFormatCode, mustDecodeHex(graphIdBinaryHex), &gid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := gid.String(); s != "3.14" {
+		t.Errorf("got %s, want 3.14", s)
+	}
+
+	err = m.Scan(testOIDs["graphid"], pgtype.BinaryFormatCode, nil, &gid)
+	if err != nil {
+		t.Error(err)
+	} else if gid.Valid {
+		t.Errorf("got %s, want NULL", gid)
+	}
+
+	var gids []ag.GraphId
+	err = m.Scan(testOIDs["_graphid"], pgtype.BinaryFormatCode, mustDecodeHex(graphIdArrayBinaryHex), &gids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gids) != 2 || gids[0].String() != "3.14" || gids[1].Valid {
+		t.Errorf("got %v, want [3.14 NULL]", gids)
+	}
+
+	// other targets go through the text representation
+	gids = nil
+	err = m.Scan(testOIDs["_graphid"], pgtype.BinaryFormatCode, mustDecodeHex(graphIdArrayBinaryHex), ag.Array(&gids))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gids) != 2 || gids[0].String() != "3.14" || gids[1].Valid {
+		t.Errorf("got %v, want [3.14 NULL]", gids)
+	}
+
+	typ, _ := m.TypeForOID(testOIDs["graphid"])
+	v, err := typ.Codec.DecodeDatabaseSQLValue(m, testOIDs["graphid"], pgtype.BinaryFormatCode, mustDecodeHex(graphIdBinaryHex))
+	if err != nil {
+		t.Error(err)
+	} else if b, ok := v.([]byte); !ok || string(b) != "3.14" {
+		t.Errorf("got %v, want 3.14", v)
+	}
+
+	typ, _ = m.TypeForOID(testOIDs["_graphid"])
+	dv, err := typ.Codec.DecodeValue(m, testOIDs["_graphid"], pgtype.BinaryFormatCode, mustDecodeHex(graphIdArrayBinaryHex))
+	if err != nil {
+		t.Error(err)
+	} else if gids, ok := dv.([]ag.GraphId); !ok || len(gids) != 2 {
+		t.Errorf("got %v, want [3.14 NULL]", dv)
+	}
+}
+
+func TestEncodeGraphIdBinary(t *testing.T) {
+	m := newTestMap(t)
+
+	gid, _ := ag.NewGraphId("3.14")
+	b, err := m.Encode(testOIDs["graphid"], pgtype.BinaryFormatCode, gid, nil)
+	if err != nil {
+		t.Error(err)
+	} else if s := hex.EncodeToString(b); s != graphIdBinaryHex {
+		t.Errorf("got %s, want %s", s, graphIdBinaryHex)
+	}
+
+	b, err = m.Encode(testOIDs["graphid"], pgtype.BinaryFormatCode, ag.GraphId{}, nil)
+	if err != nil {
+		t.Error(err)
+	} else if b != nil {
+		t.Errorf("got %x, want NULL", b)
+	}
+
+	b, err = m.Encode(testOIDs["_graphid"], pgtype.BinaryFormatCode, []ag.GraphId{gid, {}}, nil)
+	if err != nil {
+		t.Error(err)
+	} else if s := hex.EncodeToString(b); s != graphIdArrayBinaryHex {
+		t.Errorf("got %s, want %s", s, graphIdArrayBinaryHex)
+	}
+}
+
+func TestServerRegister(t *testing.T) {
+	if !*agTestServer {
+		t.SkipNow()
+	}
+
+	// Other environment variables: PGHOST, PGPORT, PGUSER, PGPASSWORD, ...
+	os.Setenv("PGDATABASE", "postgres")
+	os.Setenv("PGSSLMODE", "disable")
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close(ctx)
+
+	err = Register(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gid ag.GraphId
+	err = conn.QueryRow(ctx, `SELECT $1::graphid`, ag.GraphId{}).Scan(&gid)
+	if err != nil {
+		t.Error(err)
+	} else if gid.Valid {
+		t.Errorf("got %s, want NULL", gid)
+	}
+
+	in, _ := ag.NewGraphId("3.14")
+	err = conn.QueryRow(ctx, `SELECT $1::graphid`, in).Scan(&gid)
+	if err != nil {
+		t.Error(err)
+	} else if !gid.Equal(in) {
+		t.Errorf("got %s, want %s", gid, in)
+	}
+
+	// graphid is moved in binary format by default
+	var gids []ag.GraphId
+	err = conn.QueryRow(ctx, `SELECT $1::_graphid`, []ag.GraphId{in}).Scan(&gids)
+	if err != nil {
+		t.Error(err)
+	} else if len(gids) != 1 || !gids[0].Equal(in) {
+		t.Errorf("got %v, want [%s]", gids, in)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agpgx
+
+import (
+	"github.com/bitnine-oss/agensgraph-golang"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// binaryFormat moves a type of AgensGraph in binary format. Only the values
+// and targets of the default Go type are encoded and scanned directly. The
+// others go through the text representation.
+type binaryFormat struct {
+	// decode returns the default Go type of src
+	decode func(src []byte) (interface{}, error)
+	// text returns the text representation of src
+	text       func(src []byte) ([]byte, error)
+	planEncode func(m *pgtype.Map, value interface{}) pgtype.EncodePlan
+	planScan   func(target interface{}) pgtype.ScanPlan
+}
+
+var graphIdBinary = &binaryFormat{
+	decode: func(src []byte) (interface{}, error) {
+		var gid ag.GraphId
+		err := gid.UnmarshalBinary(src)
+		return gid, err
+	},
+	text: func(src []byte) ([]byte, error) {
+		var gid ag.GraphId
+		err := gid.UnmarshalBinary(src)
+		if err != nil {
+			return nil, err
+		}
+		return gid.MarshalText()
+	},
+	planEncode: func(m *pgtype.Map, value interface{}) pgtype.EncodePlan {
+		if _, ok := value.(ag.GraphId); ok {
+			return encodePlanGraphIdBinary{}
+		}
+		return nil
+	},
+	planScan: func(target interface{}) pgtype.ScanPlan {
+		if _, ok := target.(*ag.GraphId); ok {
+			return scanPlanGraphIdBinary{}
+		}
+		return nil
+	},
+}
+
+type encodePlanGraphIdBinary struct{}
+
+func (encodePlanGraphIdBinary) Encode(value interface{}, buf []byte) ([]byte, error) {
+	gid := value.(ag.GraphId)
+	if !gid.Valid {
+		return nil, nil
+	}
+	return gid.AppendBinary(buf)
+}
+
+type scanPlanGraphIdBinary struct{}
+
+func (scanPlanGraphIdBinary) Scan(src []byte, target interface{}) error {
+	gid := target.(*ag.GraphId)
+	if src == nil {
+		return gid.Scan(nil)
+	}
+	return gid.UnmarshalBinary(src)
+}
+
+var graphIdArrayBinary = &binaryFormat{
+	decode: func(src []byte) (interface{}, error) {
+		return ag.ParseGraphIdArrayBinary(src)
+	},
+	text: func(src []byte) ([]byte, error) {
+		gids, err := ag.ParseGraphIdArrayBinary(src)
+		if err != nil {
+			return nil, err
+		}
+		v, err := ag.Array(gids).Value()
+		if err != nil {
+			return nil, err
+		}
+		return v.([]byte), nil
+	},
+	planEncode: func(m *pgtype.Map, value interface{}) pgtype.EncodePlan {
+		if _, ok := value.([]ag.GraphId); !ok {
+			return nil
+		}
+		// the element type OID is a part of the binary format
+		t, ok := m.TypeForName("graphid")
+		if !ok {
+			return nil
+		}
+		return encodePlanGraphIdArrayBinary{t.OID}
+	},
+	planScan: func(target interface{}) pgtype.ScanPlan {
+		if _, ok := target.(*[]ag.GraphId); ok {
+			return scanPlanGraphIdArrayBinary{}
+		}
+		return nil
+	},
+}
+
+type encodePlanGraphIdArrayBinary struct {
+	elemOid uint32
+}
+
+func (p encodePlanGraphIdArrayBinary) Encode(value interface{}, buf []byte) ([]byte, error) {
+	gids := value.([]ag.GraphId)
+	if gids == nil {
+		return nil, nil
+	}
+	return ag.AppendGraphIdArrayBinary(buf, gids, p.elemOid), nil
+}
+
+type scanPlanGraphIdArrayBinary struct{}
+
+func (scanPlanGraphIdArrayBinary) Scan(src []byte, target interface{}) error {
+	gids, err := ag.ParseGraphIdArrayBinary(src)
+	if err != nil {
+		return err
+	}
+	*target.(*[]ag.GraphId) = gids
+	return nil
+}
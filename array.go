@@ -21,6 +21,7 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"iter"
 	"reflect"
 )
 
@@ -28,8 +29,9 @@ import (
 // that is a slice or an array of the following types; GraphId, and entities
 // for vertex and edge.
 //
-// If the type of dest is not *[]GraphId and []GraphId, Value of Array returns
-// an error since passing entities as parameters is not allowed.
+// Value of Array writes entities by calling ValueEntity for each element. It
+// returns an error if the element type of dest does not implement
+// EntityLoader.
 func Array(dest interface{}) interface {
 	sql.Scanner
 	driver.Valuer
@@ -42,9 +44,13 @@ func Array(dest interface{}) interface {
 
 	case *[]BasicVertex:
 		return (*basicVertexArray)(dest)
+	case []BasicVertex:
+		return (*basicVertexArray)(&dest)
 
 	case *[]BasicEdge:
 		return (*basicEdgeArray)(dest)
+	case []BasicEdge:
+		return (*basicEdgeArray)(&dest)
 	}
 
 	return elementArray{dest}
@@ -64,6 +70,137 @@ type elementsReader interface {
 	readElements(b []byte) ([]interface{}, error)
 }
 
+type elementReader interface {
+	readElement(b []byte) (advance int, data *entityData, err error)
+}
+
+type readElementFunc func(b []byte) (advance int, data *entityData, err error)
+
+func readElements(b []byte, read readElementFunc) ([]interface{}, error) {
+	var ds []interface{}
+	err := eachElement(b, read, func(_ int, d *entityData) error {
+		if d == nil {
+			ds = append(ds, nil)
+		} else {
+			ds = append(ds, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// eachElement reads elements of b one by one and calls fn for each element. d
+// is nil if the element is NULL.
+func eachElement(b []byte, read readElementFunc, fn func(i int, d *entityData) error) error {
+	n := len(b)
+	if n < 2 || b[0] != byte('[') || b[n-1] != byte(']') {
+		return fmt.Errorf("bad array representation: %s", b)
+	}
+
+	// remove surrounding brackets
+	b = b[1 : n-1]
+
+	for i := 0; len(b) > 0; i++ {
+		if i > 0 {
+			if b[0] != entitySeparator {
+				return fmt.Errorf("bad array representation: %s", b)
+			}
+			// remove comma
+			b = b[1:]
+		}
+
+		advance, d, err := read(b)
+		if err != nil {
+			return err
+		}
+
+		err = fn(i, d)
+		if err != nil {
+			return err
+		}
+
+		b = b[advance:]
+	}
+
+	return nil
+}
+
+// ErrStopScan can be returned by the callback of ScanEach to stop reading the
+// rest of the elements.
+var ErrStopScan = errors.New("stop scan")
+
+// ScanEach reads an array of vertices or edges from src one element at a time.
+// For each element, it stores the element in entity by calling ScanEntity and
+// then calls fn with the index of the element. Unlike Array, ScanEach does not
+// keep all the elements in memory.
+//
+// entity is reused for all the elements, so fn should copy what it needs to
+// keep. Note that the default behavior of ScanEntity for properties does not
+// clear the fields that are missing in the properties of an element.
+//
+// If fn returns ErrStopScan, ScanEach stops and returns nil. If fn returns any
+// other error, ScanEach stops and returns the error. If src is NULL, fn is
+// never called.
+func ScanEach(src interface{}, entity Entity, fn func(i int) error) error {
+	r, ok := entity.(elementReader)
+	if !ok {
+		return fmt.Errorf("%T cannot read array elements", entity)
+	}
+
+	if src == nil {
+		return nil
+	}
+
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("invalid source for array: %T", src)
+	}
+	if len(b) < 1 {
+		return fmt.Errorf("invalid source for array: %v", b)
+	}
+
+	err := eachElement(b, r.readElement, func(i int, d *entityData) error {
+		var err error
+		if d == nil {
+			err = ScanEntity(nil, entity)
+		} else {
+			err = ScanEntity(d, entity)
+		}
+		if err != nil {
+			return errors.New("invalid element: " + err.Error())
+		}
+
+		return fn(i)
+	})
+	if err == ErrStopScan {
+		return nil
+	}
+	return err
+}
+
+// ScanSeq returns an iterator that reads an array of vertices or edges from src
+// in the same way as ScanEach. It yields the index of each element after
+// storing the element in entity, along with a nil error. If an error occurs,
+// it yields the error as the last pair.
+func ScanSeq(src interface{}, entity Entity) iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {
+		i := -1
+		err := ScanEach(src, entity, func(j int) error {
+			i = j
+			if !yield(j, nil) {
+				return ErrStopScan
+			}
+			return nil
+		})
+		if err != nil {
+			yield(i+1, err)
+		}
+	}
+}
+
 type elementArray struct {
 	dest interface{}
 }
@@ -153,6 +290,50 @@ func (a elementArray) Scan(src interface{}) error {
 	return nil
 }
 
+var typeEntityLoader = reflect.TypeOf((*EntityLoader)(nil)).Elem()
+
 func (a elementArray) Value() (driver.Value, error) {
-	return nil, fmt.Errorf("Value() on an array of %T is not supported", a.dest)
+	// []t or *[]t
+	if a.dest == nil {
+		return nil, errors.New("Value() on an array of nil is not supported")
+	}
+
+	rv := reflect.ValueOf(a.dest)
+	rt := rv.Type()
+	addressable := false
+	if rv.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+		addressable = true
+	}
+	rk := rt.Kind()
+	if rk != reflect.Slice && rk != reflect.Array {
+		return nil, fmt.Errorf("Value() on an array of %T is not supported", a.dest)
+	}
+
+	// t.(EntityLoader) or (*t).(EntityLoader) if t is addressable
+	rte := rt.Elem()
+	if !rte.Implements(typeEntityLoader) {
+		addressable = addressable || rk == reflect.Slice
+		if !addressable || !reflect.PtrTo(rte).Implements(typeEntityLoader) {
+			return nil, fmt.Errorf("%s does not implement %s", rte, typeEntityLoader)
+		}
+	}
+
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if rk == reflect.Slice && rv.IsNil() {
+		return nil, nil
+	}
+
+	return appendEntityArray(nil, rv.Len(), func(i int) EntityLoader {
+		e := rv.Index(i)
+		if e.CanAddr() {
+			return e.Addr().Interface().(EntityLoader)
+		}
+		return e.Interface().(EntityLoader)
+	})
 }
@@ -16,7 +16,10 @@ limitations under the License.
 
 package ag
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 type testElement struct{}
 
@@ -105,4 +108,139 @@ func TestArrayValue(t *testing.T) {
 	if err == nil {
 		t.Errorf("error expected for Value() on Array")
 	}
+
+	tests := []interface{}{
+		nil,
+		0,
+		[1]testElementEx{},
+	}
+	for _, i := range tests {
+		_, err := Array(i).Value()
+		if err == nil {
+			t.Errorf("error expected for %T", i)
+		}
+	}
+}
+
+func TestScanEach(t *testing.T) {
+	src := []byte(`[v[3.1]{"name": "a", "x": 1},NULL,v[3.3]{"name": "c"}]`)
+
+	var v BasicVertex
+	var got []string
+	err := ScanEach(src, &v, func(i int) error {
+		got = append(got, v.String())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`v[3.1]{"name":"a","x":1}`, "NULL", `v[3.3]{"name":"c"}`}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %s, want %s", got[i], want[i])
+		}
+	}
+
+	n := 0
+	err = ScanEach(src, &v, func(i int) error {
+		n++
+		return ErrStopScan
+	})
+	if err != nil {
+		t.Error(err)
+	} else if n != 1 {
+		t.Errorf("got %d calls, want 1", n)
+	}
+
+	errTest := errors.New("test")
+	err = ScanEach(src, &v, func(i int) error {
+		return errTest
+	})
+	if err != errTest {
+		t.Errorf("got %v, want %v", err, errTest)
+	}
+
+	err = ScanEach(nil, &v, func(i int) error {
+		t.Error("fn called for NULL")
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestScanEachEdge(t *testing.T) {
+	src := []byte(`[e[4.1][3.1,3.2]{},e[4.2][3.2,3.3]{}]`)
+
+	var e TypedEdge[map[string]int]
+	var ids []string
+	err := ScanEach(src, &e, func(i int) error {
+		ids = append(ids, e.Id.String())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != "4.1" || ids[1] != "4.2" {
+		t.Errorf("got %v, want [4.1 4.2]", ids)
+	}
+}
+
+func TestScanEachError(t *testing.T) {
+	tests := []interface{}{
+		0,
+		[]byte(nil),
+		[]byte("["),
+		[]byte("{}"),
+		[]byte(`[v[3.1]{}v[3.2]{}]`),
+		[]byte(`[v[3.1]{},x]`),
+		[]byte(`[v[3.1]{"name": 1]`),
+	}
+	for _, src := range tests {
+		var v BasicVertex
+		err := ScanEach(src, &v, func(i int) error {
+			return nil
+		})
+		if err == nil {
+			t.Errorf("error expected for %v", src)
+		}
+	}
+}
+
+func TestScanSeq(t *testing.T) {
+	src := []byte(`[v[3.1]{},v[3.2]{},v[3.3]{}]`)
+
+	var v BasicVertex
+	var ids []string
+	for i, err := range ScanSeq(src, &v) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, v.Id.String())
+		if i == 1 {
+			break
+		}
+	}
+	if len(ids) != 2 || ids[1] != "3.2" {
+		t.Errorf("got %v, want [3.1 3.2]", ids)
+	}
+
+	var last error
+	n := 0
+	for i, err := range ScanSeq([]byte(`[v[3.1]{},x]`), &v) {
+		if err != nil {
+			if i != 1 {
+				t.Errorf("got error at %d, want 1", i)
+			}
+			last = err
+			break
+		}
+		n++
+	}
+	if n != 1 || last == nil {
+		t.Errorf("got %d elements and %v, want 1 and an error", n, last)
+	}
 }
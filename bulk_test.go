@@ -0,0 +1,274 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGraph serves labels, sequences and COPY of a graph for BulkLoader.
+type fakeGraph struct {
+	mu     sync.Mutex
+	labels [][]driver.Value
+	seq    int64
+	copied []string // "table: args..."
+}
+
+func newFakeGraph(labels ...[]driver.Value) (*Graph, *fakeDriver, *fakeGraph) {
+	fg := &fakeGraph{labels: labels}
+	d := &fakeDriver{}
+	d.query = func(query string, args []driver.NamedValue) (driver.Rows, error) {
+		fg.mu.Lock()
+		defer fg.mu.Unlock()
+
+		switch {
+		case strings.Contains(query, "FROM ag_label"):
+			return &fakeRows{labelColumns, append([][]driver.Value(nil), fg.labels...)}, nil
+		case strings.Contains(query, "nextval"):
+			var values [][]driver.Value
+			for i := int64(0); i < args[1].Value.(int64); i++ {
+				fg.seq++
+				values = append(values, []driver.Value{fg.seq})
+			}
+			return &fakeRows{[]string{"nextval"}, values}, nil
+		}
+		return nil, fmt.Errorf("unexpected query: %s", query)
+	}
+	d.exec = func(query string, args []driver.NamedValue) error {
+		fg.mu.Lock()
+		defer fg.mu.Unlock()
+
+		const createV = `CREATE VLABEL IF NOT EXISTS `
+		switch {
+		case strings.HasPrefix(query, createV):
+			name := strings.Trim(strings.TrimPrefix(query, createV), `"`)
+			id := int64(len(fg.labels) + 10)
+			fg.labels = append(fg.labels, []driver.Value{"g", name, "v", id, []byte(`[]`)})
+		case strings.HasPrefix(query, "COPY "):
+			if len(args) < 1 {
+				return nil
+			}
+			var vals []string
+			for _, a := range args {
+				vals = append(vals, fmt.Sprint(a.Value))
+			}
+			table := strings.Fields(query)[1]
+			fg.copied = append(fg.copied, table+": "+strings.Join(vals, " "))
+		}
+		return nil
+	}
+	return NewGraph(d, "g"), d, fg
+}
+
+func TestBulkLoaderVertices(t *testing.T) {
+	g, d, fg := newFakeGraph([]driver.Value{"g", "person", "v", int64(3), []byte(`[]`)})
+	defer g.Close()
+
+	l := NewBulkLoader(g)
+	l.BatchSize = 2
+	l.CreateLabels = true
+	var progress []int
+	l.Progress = func(n int) {
+		progress = append(progress, n)
+	}
+
+	vs := []BasicVertex{
+		bulkVertex("person", map[string]interface{}{"name": "a"}),
+		bulkVertex("city", nil),
+		bulkVertex("person", map[string]interface{}{"name": "b\tc"}),
+	}
+	ids, err := l.LoadVertices(context.Background(), slices.Values(vs))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"3.1", "11.2", "3.3"}
+	var got []string
+	for _, id := range ids {
+		got = append(got, id.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	copied := []string{
+		`"g"."person": 3.1 {"name":"a"}`,
+		`"g"."city": 11.2 {}`,
+		`"g"."person": 3.3 {"name":"b\tc"}`,
+	}
+	if !reflect.DeepEqual(fg.copied, copied) {
+		t.Errorf("got %q, want %q", fg.copied, copied)
+	}
+	if !reflect.DeepEqual(progress, []int{2, 3}) {
+		t.Errorf("got %v, want [2 3]", progress)
+	}
+
+	// a transaction for each batch
+	txs := 0
+	for _, s := range d.statements() {
+		if s == "BEGIN" {
+			txs++
+		}
+	}
+	if txs != 2 {
+		t.Errorf("got %d transactions, want 2", txs)
+	}
+}
+
+func bulkVertex(label string, props map[string]interface{}) BasicVertex {
+	v := BasicVertex{Properties: props}
+	v.Label = label
+	return v
+}
+
+// bulkEdge returns an edge whose start and end IDs are NULL if they are empty.
+func bulkEdge(label, start, end string) BasicEdge {
+	var e BasicEdge
+	e.Label = label
+	if start != "" {
+		e.Start = mustNewGraphId(start)
+	}
+	if end != "" {
+		e.End = mustNewGraphId(end)
+	}
+	return e
+}
+
+func TestBulkLoaderEdges(t *testing.T) {
+	g, _, fg := newFakeGraph([]driver.Value{"g", "knows", "e", int64(4), []byte(`[]`)})
+	defer g.Close()
+
+	l := NewBulkLoader(g)
+	es := []BasicEdge{
+		bulkEdge("knows", "3.1", "3.2"),
+	}
+	ids, err := l.LoadEdges(context.Background(), slices.Values(es))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0].String() != "4.1" {
+		t.Errorf("got %v, want [4.1]", ids)
+	}
+
+	copied := []string{`"g"."knows": 4.1 3.1 3.2 {}`}
+	if !reflect.DeepEqual(fg.copied, copied) {
+		t.Errorf("got %q, want %q", fg.copied, copied)
+	}
+}
+
+func TestBulkLoaderError(t *testing.T) {
+	g, _, _ := newFakeGraph(
+		[]driver.Value{"g", "person", "v", int64(3), []byte(`[]`)},
+		[]driver.Value{"g", "knows", "e", int64(4), []byte(`[]`)})
+	defer g.Close()
+
+	ctx := context.Background()
+	l := NewBulkLoader(g)
+	l.BatchSize = 1
+
+	vertexTests := [][]BasicVertex{
+		{bulkVertex("", nil)},
+		{bulkVertex("city", nil)},
+		{bulkVertex("knows", nil)},
+	}
+	for _, vs := range vertexTests {
+		_, err := l.LoadVertices(ctx, slices.Values(vs))
+		if err == nil {
+			t.Errorf("error expected for %v", vs)
+		}
+	}
+
+	edgeTests := [][]BasicEdge{
+		{bulkEdge("knows", "", "3.1")},
+		{bulkEdge("knows", "3.1", "")},
+		{bulkEdge("person", "3.1", "3.1")},
+	}
+	for _, es := range edgeTests {
+		_, err := l.LoadEdges(ctx, slices.Values(es))
+		if err == nil {
+			t.Errorf("error expected for %v", es)
+		}
+	}
+
+	// the batches before an error remain loaded
+	vs := []BasicVertex{bulkVertex("person", nil), bulkVertex("city", nil)}
+	ids, err := l.LoadVertices(ctx, slices.Values(vs))
+	if err == nil {
+		t.Error("error expected")
+	}
+	if len(ids) != 1 {
+		t.Errorf("got %v, want 1 GraphId", ids)
+	}
+}
+
+func TestServerBulkLoader(t *testing.T) {
+	skipUnlessServerTest(t)
+
+	g := mustOpenGraph(t)
+	defer g.Close()
+
+	ctx := context.Background()
+	l := NewBulkLoader(g)
+	l.BatchSize = 100
+	l.CreateLabels = true
+
+	vs := make([]BasicVertex, 250)
+	for i := range vs {
+		vs[i] = bulkVertex("bulk_v", map[string]interface{}{"i": i})
+	}
+	vids, err := l.LoadVertices(ctx, slices.Values(vs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vids) != len(vs) {
+		t.Fatalf("got %d GraphIds, want %d", len(vids), len(vs))
+	}
+
+	es := make([]BasicEdge, len(vids)-1)
+	for i := range es {
+		es[i].Label, es[i].Start, es[i].End = "bulk_e", vids[i], vids[i+1]
+	}
+	eids, err := l.LoadEdges(ctx, slices.Values(es))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v BasicVertex
+	err = g.QueryRow(`MATCH (n:bulk_v) WHERE id(n) = $1 RETURN n`, vids[42]).Scan(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Properties["i"] != float64(42) {
+		t.Errorf("got %v, want 42", v.Properties["i"])
+	}
+
+	var e BasicEdge
+	err = g.QueryRow(`MATCH ()-[r:bulk_e]->() WHERE id(r) = $1 RETURN r`, eids[0]).Scan(&e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Start.Equal(vids[0]) || !e.End.Equal(vids[1]) {
+		t.Errorf("got %s, want %s-%s", e, vids[0], vids[1])
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Queryer is an interface used by the functions that read the catalog of
+// AgensGraph. *sql.DB, *sql.Conn, *sql.Tx and *Graph implement it.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// LabelKind is the kind of a label.
+type LabelKind byte
+
+const (
+	// VertexLabel is the kind of vertex labels.
+	VertexLabel LabelKind = 'v'
+	// EdgeLabel is the kind of edge labels.
+	EdgeLabel LabelKind = 'e'
+)
+
+func (k LabelKind) String() string {
+	switch k {
+	case VertexLabel:
+		return "vertex"
+	case EdgeLabel:
+		return "edge"
+	default:
+		return fmt.Sprintf("LabelKind(%q)", byte(k))
+	}
+}
+
+// Label is a label of a graph in ag_label.
+type Label struct {
+	// Graph is the name of the graph the label belongs to.
+	Graph string
+	Name  string
+	Kind  LabelKind
+	// Id is the label ID, which is the label part of GraphIds of the label.
+	Id uint16
+	// Parents are the names of the labels the label inherits from, in the
+	// order of inheritance.
+	Parents []string
+}
+
+// ErrLabelNotFound is returned if there is no label that matches the request.
+var ErrLabelNotFound = errors.New("label not found")
+
+// Graphs returns the names of all the graphs in ag_graph.
+func Graphs(ctx context.Context, q Queryer) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `SELECT graphname FROM ag_graph ORDER BY graphname`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// labelsQuery returns the labels of graph $1. Parent labels are returned as
+// a JSON array not to depend on the array support of drivers.
+const labelsQuery = `SELECT g.graphname, l.labname, l.labkind, l.labid,
+  array_to_json(ARRAY(
+    SELECT p.labname FROM pg_inherits i JOIN ag_label p ON p.relid = i.inhparent
+    WHERE i.inhrelid = l.relid ORDER BY i.inhseqno))
+FROM ag_label l JOIN ag_graph g ON g.oid = l.graphid
+WHERE g.graphname = $1`
+
+// Labels returns all the labels of graph ordered by the label ID.
+func Labels(ctx context.Context, q Queryer, graph string) ([]Label, error) {
+	return queryLabels(ctx, q, labelsQuery+` ORDER BY l.labid`, graph)
+}
+
+// LabelOf returns the label of gid in graph. It returns ErrLabelNotFound if
+// the label ID of gid is not in graph.
+func LabelOf(ctx context.Context, q Queryer, graph string, gid GraphId) (Label, error) {
+	if !gid.Valid {
+		return Label{}, errors.New("invalid graphid: NULL")
+	}
+
+	labels, err := queryLabels(ctx, q, labelsQuery+` AND l.labid = $2`, graph, int64(gid.LabelId()))
+	if err != nil {
+		return Label{}, err
+	}
+	if len(labels) < 1 {
+		return Label{}, ErrLabelNotFound
+	}
+	return labels[0], nil
+}
+
+func queryLabels(ctx context.Context, q Queryer, query string, args ...interface{}) ([]Label, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var labels []Label
+	for rows.Next() {
+		var l Label
+		var kind string
+		var id int64
+		var parents []byte
+		err = rows.Scan(&l.Graph, &l.Name, &kind, &id, &parents)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(kind) != 1 || (LabelKind(kind[0]) != VertexLabel && LabelKind(kind[0]) != EdgeLabel) {
+			return nil, fmt.Errorf("invalid kind of label %s: %q", l.Name, kind)
+		}
+		l.Kind = LabelKind(kind[0])
+
+		if id < 0 || id >= 1<<labelBit {
+			return nil, fmt.Errorf("invalid ID of label %s: %d", l.Name, id)
+		}
+		l.Id = uint16(id)
+
+		err = json.Unmarshal(parents, &l.Parents)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parents of label %s: %s", l.Name, err)
+		}
+
+		labels = append(labels, l)
+	}
+	return labels, rows.Err()
+}
+
+// Labels returns all the labels of the graph of g ordered by the label ID.
+func (g *Graph) Labels(ctx context.Context) ([]Label, error) {
+	return Labels(ctx, g.db, g.name)
+}
+
+// LabelOf returns the label of gid in the graph of g.
+func (g *Graph) LabelOf(ctx context.Context, gid GraphId) (Label, error) {
+	return LabelOf(ctx, g.db, g.name, gid)
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var labelColumns = []string{"graphname", "labname", "labkind", "labid", "parents"}
+
+// newLabelDB returns *sql.DB whose label queries return labels, filtered by
+// the label ID if it is given.
+func newLabelDB(labels [][]driver.Value) *sql.DB {
+	db, d := newFakeDB()
+	d.query = func(query string, args []driver.NamedValue) (driver.Rows, error) {
+		if strings.Contains(query, "graphname FROM ag_graph") {
+			return &fakeRows{[]string{"graphname"}, [][]driver.Value{{"a"}, {"b"}}}, nil
+		}
+
+		var values [][]driver.Value
+		for _, l := range labels {
+			if len(args) > 1 && l[3] != args[1].Value {
+				continue
+			}
+			values = append(values, l)
+		}
+		return &fakeRows{labelColumns, values}, nil
+	}
+	return db
+}
+
+func TestLabels(t *testing.T) {
+	db := newLabelDB([][]driver.Value{
+		{"g", "ag_vertex", "v", int64(1), []byte(`[]`)},
+		{"g", "ag_edge", "e", int64(2), []byte(`[]`)},
+		{"g", "person", "v", int64(3), []byte(`["ag_vertex"]`)},
+		{"g", "knows", "e", int64(4), []byte(`["ag_edge"]`)},
+	})
+	defer db.Close()
+
+	ctx := context.Background()
+	labels, err := Labels(ctx, db, "g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Label{
+		{"g", "ag_vertex", VertexLabel, 1, []string{}},
+		{"g", "ag_edge", EdgeLabel, 2, []string{}},
+		{"g", "person", VertexLabel, 3, []string{"ag_vertex"}},
+		{"g", "knows", EdgeLabel, 4, []string{"ag_edge"}},
+	}
+	if !reflect.DeepEqual(labels, want) {
+		t.Errorf("got %v, want %v", labels, want)
+	}
+
+	l, err := LabelOf(ctx, db, "g", mustNewGraphId("3.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(l, want[2]) {
+		t.Errorf("got %v, want %v", l, want[2])
+	}
+
+	_, err = LabelOf(ctx, db, "g", mustNewGraphId("5.1"))
+	if err != ErrLabelNotFound {
+		t.Errorf("got %v, want %v", err, ErrLabelNotFound)
+	}
+	_, err = LabelOf(ctx, db, "g", mustNewGraphId("NULL"))
+	if err == nil {
+		t.Error("error expected for NULL")
+	}
+
+	graphs, err := Graphs(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(graphs, []string{"a", "b"}) {
+		t.Errorf("got %v, want [a b]", graphs)
+	}
+}
+
+func TestLabelsError(t *testing.T) {
+	tests := [][]driver.Value{
+		{"g", "x", "r", int64(1), []byte(`[]`)},
+		{"g", "x", "v", int64(65536), []byte(`[]`)},
+		{"g", "x", "v", int64(1), []byte(`{}`)},
+	}
+	for _, l := range tests {
+		db := newLabelDB([][]driver.Value{l})
+		_, err := Labels(context.Background(), db, "g")
+		if err == nil {
+			t.Errorf("error expected for %v", l)
+		}
+		db.Close()
+	}
+}
+
+func TestLabelKindString(t *testing.T) {
+	if s := VertexLabel.String(); s != "vertex" {
+		t.Errorf("got %s, want vertex", s)
+	}
+	if s := EdgeLabel.String(); s != "edge" {
+		t.Errorf("got %s, want edge", s)
+	}
+}
+
+func TestServerLabels(t *testing.T) {
+	skipUnlessServerTest(t)
+
+	db := mustOpenGraph(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	_, err := db.Exec(`CREATE VLABEL IF NOT EXISTS catalog_parent`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE VLABEL IF NOT EXISTS catalog_child INHERITS (catalog_parent)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gid GraphId
+	err = db.QueryRow(`CREATE (n:catalog_child) RETURN id(n)`).Scan(&gid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := db.LabelOf(ctx, gid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "catalog_child" || l.Kind != VertexLabel || l.Id != gid.LabelId() {
+		t.Errorf("got %v", l)
+	}
+	if len(l.Parents) != 1 || l.Parents[0] != "catalog_parent" {
+		t.Errorf("got %v, want [catalog_parent]", l.Parents)
+	}
+
+	labels, err := db.Labels(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, x := range labels {
+		if x.Name == l.Name && x.Id == l.Id {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("%s not found in %v", l.Name, labels)
+	}
+
+	graphs, err := Graphs(ctx, db.DB())
+	if err != nil {
+		t.Fatal(err)
+	}
+	found = false
+	for _, g := range graphs {
+		if g == agTestGraphName {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("%s not found in %v", agTestGraphName, graphs)
+	}
+}
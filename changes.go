@@ -0,0 +1,410 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// propertiesSnapshotSaver is implemented by TrackedVertexHeader and
+// TrackedEdgeHeader, so ScanEntity keeps the properties of the entities that
+// embed them.
+type propertiesSnapshotSaver interface {
+	savePropertiesSnapshot(b []byte)
+}
+
+type propertiesSnapshotLoader interface {
+	PropertiesSnapshot() []byte
+}
+
+// TrackedVertexHeader is VertexHeader that also keeps a copy of the properties
+// of the vertex when it is scanned by ScanEntity, so that Changes can tell
+// what has changed since then. The copy costs memory for every scanned vertex,
+// so embed it instead of VertexHeader only if Changes is used.
+type TrackedVertexHeader struct {
+	VertexHeader
+
+	snapshot []byte // properties scanned by ScanEntity
+}
+
+// SaveEntity implements EntitySaver interface.
+func (h *TrackedVertexHeader) SaveEntity(valid bool, core interface{}) error {
+	h.snapshot = nil
+	return h.VertexHeader.SaveEntity(valid, core)
+}
+
+// PropertiesSnapshot returns the properties of the vertex when it was scanned
+// by ScanEntity, or nil if it has not been scanned.
+func (h TrackedVertexHeader) PropertiesSnapshot() []byte {
+	return h.snapshot
+}
+
+func (h *TrackedVertexHeader) savePropertiesSnapshot(b []byte) {
+	h.snapshot = append([]byte(nil), b...)
+}
+
+// TrackedEdgeHeader is EdgeHeader that also keeps a copy of the properties of
+// the edge when it is scanned by ScanEntity. See TrackedVertexHeader.
+type TrackedEdgeHeader struct {
+	EdgeHeader
+
+	snapshot []byte // properties scanned by ScanEntity
+}
+
+// SaveEntity implements EntitySaver interface.
+func (h *TrackedEdgeHeader) SaveEntity(valid bool, core interface{}) error {
+	h.snapshot = nil
+	return h.EdgeHeader.SaveEntity(valid, core)
+}
+
+// PropertiesSnapshot returns the properties of the edge when it was scanned by
+// ScanEntity, or nil if it has not been scanned.
+func (h TrackedEdgeHeader) PropertiesSnapshot() []byte {
+	return h.snapshot
+}
+
+func (h *TrackedEdgeHeader) savePropertiesSnapshot(b []byte) {
+	h.snapshot = append([]byte(nil), b...)
+}
+
+// TrackedVertex is BasicVertex that embeds TrackedVertexHeader instead of
+// VertexHeader, so Changes can be used over it once it is scanned.
+type TrackedVertex struct {
+	TrackedVertexHeader
+	Properties map[string]interface{}
+}
+
+func (v TrackedVertex) String() string {
+	return BasicVertex{v.VertexHeader, v.Properties}.String()
+}
+
+// SaveProperties implements PropertiesSaver interface. It is the same as
+// SaveProperties of BasicVertex.
+func (v *TrackedVertex) SaveProperties(b []byte) error {
+	var bv BasicVertex
+	err := bv.SaveProperties(b)
+	v.Properties = bv.Properties
+	return err
+}
+
+// LoadProperties implements PropertiesLoader interface. It is the same as
+// LoadProperties of BasicVertex.
+func (v TrackedVertex) LoadProperties() ([]byte, error) {
+	return BasicVertex{Properties: v.Properties}.LoadProperties()
+}
+
+// Scan implements the database/sql Scanner interface. It calls ScanEntity.
+func (v *TrackedVertex) Scan(src interface{}) error {
+	return ScanEntity(src, v)
+}
+
+// Value implements the database/sql/driver Valuer interface. It calls
+// ValueEntity.
+func (v TrackedVertex) Value() (driver.Value, error) {
+	return ValueEntity(v)
+}
+
+// TrackedEdge is BasicEdge that embeds TrackedEdgeHeader instead of
+// EdgeHeader, so Changes can be used over it once it is scanned.
+type TrackedEdge struct {
+	TrackedEdgeHeader
+	Properties map[string]interface{}
+}
+
+func (e TrackedEdge) String() string {
+	return BasicEdge{e.EdgeHeader, e.Properties}.String()
+}
+
+// SaveProperties implements PropertiesSaver interface. It is the same as
+// SaveProperties of BasicEdge.
+func (e *TrackedEdge) SaveProperties(b []byte) error {
+	var be BasicEdge
+	err := be.SaveProperties(b)
+	e.Properties = be.Properties
+	return err
+}
+
+// LoadProperties implements PropertiesLoader interface. It is the same as
+// LoadProperties of BasicEdge.
+func (e TrackedEdge) LoadProperties() ([]byte, error) {
+	return BasicEdge{Properties: e.Properties}.LoadProperties()
+}
+
+// Scan implements the database/sql Scanner interface. It calls ScanEntity.
+func (e *TrackedEdge) Scan(src interface{}) error {
+	return ScanEntity(src, e)
+}
+
+// Value implements the database/sql/driver Valuer interface. It calls
+// ValueEntity.
+func (e TrackedEdge) Value() (driver.Value, error) {
+	return ValueEntity(e)
+}
+
+// ErrNoSnapshot is returned by Changes if the entity has no snapshot of its
+// properties.
+var ErrNoSnapshot = errors.New("no snapshot of properties")
+
+// PropertySet is a property to set. Path has more than one key if the property
+// is in a nested object.
+type PropertySet struct {
+	Path  []string
+	Value json.RawMessage
+}
+
+// PropertiesDiff is the difference between two versions of the properties of
+// an entity. SetChanges of cypher.Builder adds the SET and REMOVE clauses that
+// apply it.
+type PropertiesDiff struct {
+	// Set is the properties that are added or changed in the order of their
+	// paths.
+	Set []PropertySet
+	// Remove is the paths of the properties that are removed in order.
+	Remove [][]string
+}
+
+// Empty returns true if d has no changes.
+func (d PropertiesDiff) Empty() bool {
+	return len(d.Set) < 1 && len(d.Remove) < 1
+}
+
+// DiffProperties returns the difference from properties old to properties new,
+// which are JSON objects. Nested objects are compared key by key, so only the
+// properties in them that differ are in the result. Other values, including
+// arrays, are compared as a whole.
+func DiffProperties(old, new []byte) (PropertiesDiff, error) {
+	var o, n map[string]json.RawMessage
+	err := json.Unmarshal(old, &o)
+	if err != nil {
+		return PropertiesDiff{}, errors.New("invalid old properties: " + err.Error())
+	}
+	err = json.Unmarshal(new, &n)
+	if err != nil {
+		return PropertiesDiff{}, errors.New("invalid new properties: " + err.Error())
+	}
+
+	var d PropertiesDiff
+	err = d.diffObjects(nil, o, n)
+	if err != nil {
+		return PropertiesDiff{}, err
+	}
+	return d, nil
+}
+
+func (d *PropertiesDiff) diffObjects(path []string, old, new map[string]json.RawMessage) error {
+	keys := make([]string, 0, len(old)+len(new))
+	for k := range old {
+		keys = append(keys, k)
+	}
+	for k := range new {
+		if _, ok := old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		p := append(path[:len(path):len(path)], k)
+
+		o, inOld := old[k]
+		n, inNew := new[k]
+		switch {
+		case !inNew:
+			d.Remove = append(d.Remove, p)
+		case !inOld:
+			d.Set = append(d.Set, PropertySet{p, n})
+		case isJSONObject(o) && isJSONObject(n):
+			var oo, no map[string]json.RawMessage
+			err := json.Unmarshal(o, &oo)
+			if err == nil {
+				err = json.Unmarshal(n, &no)
+			}
+			if err == nil {
+				err = d.diffObjects(p, oo, no)
+			}
+			if err != nil {
+				return err
+			}
+		default:
+			eq, err := jsonEqual(o, n)
+			if err != nil {
+				return err
+			}
+			if !eq {
+				d.Set = append(d.Set, PropertySet{p, n})
+			}
+		}
+	}
+	return nil
+}
+
+func isJSONObject(b []byte) bool {
+	b = bytes.TrimSpace(b)
+	return len(b) > 0 && b[0] == '{'
+}
+
+// jsonEqual compares JSON values regardless of spaces and the order of keys.
+// Numbers are compared by value, so 1 and 1.0 are equal.
+func jsonEqual(a, b []byte) (bool, error) {
+	if bytes.Equal(a, b) {
+		return true, nil
+	}
+
+	var x, y interface{}
+	err := unmarshalUseNumber(a, &x)
+	if err == nil {
+		err = unmarshalUseNumber(b, &y)
+	}
+	if err != nil {
+		return false, err
+	}
+	return jsonValueEqual(x, y), nil
+}
+
+// jsonValueEqual compares the values decoded by unmarshalUseNumber.
+func jsonValueEqual(x, y interface{}) bool {
+	switch x := x.(type) {
+	case json.Number:
+		y, ok := y.(json.Number)
+		return ok && normalizeNumber(x) == normalizeNumber(y)
+	case []interface{}:
+		y, ok := y.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonValueEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := y.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !jsonValueEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	default:
+		return x == y
+	}
+}
+
+// normalizeNumber returns JSON number n as its significant digits and exponent
+// so that the numbers of the same value have the same text. n is returned as
+// it is if its exponent does not fit in int64.
+func normalizeNumber(n json.Number) string {
+	s := string(n)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	var exp int64
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 64)
+		if err != nil {
+			return string(n)
+		}
+		s, exp = s[:i], e
+	}
+	digits := s
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		digits = s[:i] + s[i+1:]
+		exp -= int64(len(s) - i - 1)
+	}
+
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return "0"
+	}
+	trimmed := strings.TrimRight(digits, "0")
+	exp += int64(len(digits) - len(trimmed))
+	return sign + trimmed + "e" + strconv.FormatInt(exp, 10)
+}
+
+func unmarshalUseNumber(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// Changes returns the difference from the properties of entity when it was
+// scanned by ScanEntity to its current properties, which are given as
+// ValueEntity does. The properties that the type of entity does not keep, such
+// as the ones missing in its "ag" tags, are not taken as removed.
+//
+// Entities that embed TrackedVertexHeader or TrackedEdgeHeader, such as
+// TrackedVertex and TrackedEdge, keep a snapshot of their properties when they
+// are scanned. Changes returns ErrNoSnapshot for other entities, including
+// BasicVertex and BasicEdge, and for the entities that have not been scanned.
+func Changes(entity EntityLoader) (PropertiesDiff, error) {
+	s, ok := entity.(propertiesSnapshotLoader)
+	if !ok {
+		return PropertiesDiff{}, ErrNoSnapshot
+	}
+	old := s.PropertiesSnapshot()
+	if old == nil {
+		return PropertiesDiff{}, ErrNoSnapshot
+	}
+
+	old, err := projectProperties(entity, old)
+	if err != nil {
+		return PropertiesDiff{}, err
+	}
+	new, err := loadProperties(entity)
+	if err != nil {
+		return PropertiesDiff{}, err
+	}
+	return DiffProperties(old, new)
+}
+
+// projectProperties returns properties b as they are after being stored in an
+// entity of the type of entity and loaded back. Properties that the type does
+// not keep are dropped, so they are not taken as removed.
+func projectProperties(entity interface{}, b []byte) ([]byte, error) {
+	t := reflect.TypeOf(entity)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	e := reflect.New(t).Interface()
+
+	// SaveProperties may reuse b
+	b = append([]byte(nil), b...)
+	var err error
+	if p, ok := e.(PropertiesSaver); ok {
+		err = p.SaveProperties(b)
+	} else {
+		err = UnmarshalProperties(b, e)
+	}
+	if err != nil {
+		return nil, errors.New("invalid properties snapshot: " + err.Error())
+	}
+	return loadProperties(e)
+}
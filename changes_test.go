@@ -0,0 +1,238 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"strings"
+	"testing"
+)
+
+// diffString returns d as "path=value" for each set and "-path" for each
+// remove.
+func diffString(d PropertiesDiff) string {
+	var s []string
+	for _, p := range d.Set {
+		s = append(s, strings.Join(p.Path, ".")+"="+string(p.Value))
+	}
+	for _, path := range d.Remove {
+		s = append(s, "-"+strings.Join(path, "."))
+	}
+	return strings.Join(s, " ")
+}
+
+func TestDiffProperties(t *testing.T) {
+	tests := []struct {
+		old, new string
+		diff     string
+	}{
+		{`{}`, `{}`, ``},
+		{`{"a": 1, "b": [1, 2]}`, `{"b":[1,2],"a":2}`, `a=2`},
+		{`{"a": 1, "b": [1, 2.50], "c": -0, "d": 1e2}`, `{"a": 1.0, "b": [1.0, 2.5], "c": 0.0, "d": 100}`, ``},
+		{`{"a": 1.5}`, `{"a": 15e-1}`, ``},
+		{`{"a": 10}`, `{"a": 1}`, `a=1`},
+		{`{"a": -1}`, `{"a": 1}`, `a=1`},
+		{`{"a": 1}`, `{"a": "1"}`, `a="1"`},
+		{`{"a": 1}`, `{"b": "x"}`, `b="x" -a`},
+		{
+			`{"name": "a", "address": {"city": "x", "zip": 1, "geo": {"lat": 1, "lng": 2}}}`,
+			`{"name": "a", "address": {"city": "y", "geo": {"lat": 1, "lng": 3}, "street": "s"}}`,
+			`address.city="y" address.geo.lng=3 address.street="s" -address.zip`,
+		},
+		{`{"a": {"b": 1}}`, `{"a": [1]}`, `a=[1]`},
+		{`{"a": {"x": 1}}`, `{"a": {"y": 2}}`, `a.y=2 -a.x`},
+		{`{"a": {"k": {"x": 1}}}`, `{"a": {"k": {"x": 1}}}`, ``},
+		{`{"a": 1}`, `{"a": null}`, `a=null`},
+	}
+	for _, c := range tests {
+		d, err := DiffProperties([]byte(c.old), []byte(c.new))
+		if err != nil {
+			t.Errorf("%s -> %s: %s", c.old, c.new, err)
+			continue
+		}
+		if s := diffString(d); s != c.diff {
+			t.Errorf("%s -> %s: got %s, want %s", c.old, c.new, s, c.diff)
+		}
+		if d.Empty() != (c.diff == "") {
+			t.Errorf("%s -> %s: got Empty %t", c.old, c.new, d.Empty())
+		}
+	}
+
+	_, err := DiffProperties([]byte(`[]`), []byte(`{}`))
+	if err == nil {
+		t.Error("error expected for non-object")
+	}
+}
+
+type changesVertex struct {
+	TrackedVertexHeader
+	Name string `ag:"name"`
+	Age  int    `ag:"age,omitempty"`
+	City string `ag:"address.city"`
+}
+
+func TestChanges(t *testing.T) {
+	var v TrackedVertex
+	_, err := Changes(v)
+	if err != ErrNoSnapshot {
+		t.Errorf("got %v, want %v", err, ErrNoSnapshot)
+	}
+
+	err = v.Scan([]byte(`v[3.1]{"name": "a", "tags": ["x"], "address": {"city": "c", "zip": 1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := Changes(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Empty() {
+		t.Errorf("got %v, want no changes", d)
+	}
+
+	v.Properties["name"] = "b"
+	delete(v.Properties["address"].(map[string]interface{}), "zip")
+	delete(v.Properties, "tags")
+	d, err = Changes(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, want := diffString(d), `name="b" -address.zip -tags`; s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+
+	// a NULL vertex has no snapshot
+	err = v.Scan(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Changes(v)
+	if err != ErrNoSnapshot {
+		t.Errorf("got %v, want %v", err, ErrNoSnapshot)
+	}
+
+	// only tracked entities keep a snapshot
+	var bv BasicVertex
+	err = bv.Scan([]byte(`v[3.1]{"name": "a"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Changes(bv)
+	if err != ErrNoSnapshot {
+		t.Errorf("got %v, want %v", err, ErrNoSnapshot)
+	}
+}
+
+func TestChangesTagged(t *testing.T) {
+	// "zip" and "other" are not kept by changesVertex, so they are not removed
+	var v changesVertex
+	err := ScanEntity([]byte(`v[3.1]{"name": "a", "age": 0, "other": 1, "address": {"city": "c", "zip": 1}}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := Changes(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Empty() {
+		t.Errorf("got %v, want no changes", d)
+	}
+
+	v.Age = 3
+	v.City = "d"
+	d, err = Changes(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, want := diffString(d), `address.city="d" age=3`; s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+}
+
+// the fields of the header are not properties of an untagged entity
+func TestChangesUntagged(t *testing.T) {
+	var v struct {
+		TrackedVertexHeader
+		Name string `json:"name"`
+	}
+	err := ScanEntity([]byte(`v[3.1]{"name": "a"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := Changes(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Empty() {
+		t.Errorf("got %s, want no changes", diffString(d))
+	}
+
+	v.Name = "b"
+	d, err = Changes(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, want := diffString(d), `name="b"`; s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+}
+
+func TestEdgeChanges(t *testing.T) {
+	var e struct {
+		TrackedEdgeHeader
+		W int `ag:"w"`
+	}
+	err := ScanEntity([]byte(`e[4.1][3.1,3.2]{"w": 1}`), &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.W = 2
+	d, err := Changes(&e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, want := diffString(d), `w=2`; s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+}
+
+func TestTrackedEdge(t *testing.T) {
+	var e TrackedEdge
+	err := e.Scan([]byte(`e[4.1][3.1,3.2]{"w": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Properties["w"] = 2
+	e.Properties["x"] = true
+	d, err := Changes(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, want := diffString(d), `w=2 x=true`; s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+
+	if s, want := e.String(), `e[4.1][3.1,3.2]{"w":2,"x":true}`; s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+	v, err := e.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, want := string(v.([]byte)), `e[4.1][3.1,3.2]{"w":2,"x":true}`; s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+}
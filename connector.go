@@ -0,0 +1,131 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConnectorOption is an option of NewConnector.
+type ConnectorOption func(c *connector)
+
+// WithGraphPath sets graph_path of every connection to graph.
+func WithGraphPath(graph string) ConnectorOption {
+	return WithInitStatements(`SET graph_path = ` + quoteIdentifier(graph))
+}
+
+// WithSearchPath sets search_path of every connection to schemas.
+func WithSearchPath(schemas ...string) ConnectorOption {
+	quoted := make([]string, len(schemas))
+	for i, s := range schemas {
+		quoted[i] = quoteIdentifier(s)
+	}
+	return WithInitStatements(`SET search_path = ` + strings.Join(quoted, ", "))
+}
+
+// WithStatementTimeout sets statement_timeout of every connection to d in
+// milliseconds. 0 disables the timeout.
+func WithStatementTimeout(d time.Duration) ConnectorOption {
+	ms := int64(d / time.Millisecond)
+	return WithInitStatements(`SET statement_timeout = ` + strconv.FormatInt(ms, 10))
+}
+
+// WithInitStatements runs stmts on every connection as they are.
+func WithInitStatements(stmts ...string) ConnectorOption {
+	return func(c *connector) {
+		c.stmts = append(c.stmts, stmts...)
+	}
+}
+
+type connector struct {
+	base  driver.Connector
+	stmts []string
+}
+
+// NewConnector returns driver.Connector that initializes AgensGraph sessions.
+// Every connection created by base runs the statements given by opts, in
+// order, before it is used. If any of them fails, the connection is closed and
+// the error is returned.
+//
+// Since the settings are made when a connection is created, all the pooled
+// connections of sql.OpenDB share them.
+//
+//	base, _ := pq.NewConnector("")
+//	db := sql.OpenDB(ag.NewConnector(base, ag.WithGraphPath("g")))
+func NewConnector(base driver.Connector, opts ...ConnectorOption) driver.Connector {
+	c := &connector{base: base}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Connect implements the database/sql/driver Connector interface.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stmt := range c.stmts {
+		err = execDriverConn(ctx, conn, stmt)
+		if err != nil {
+			conn.Close()
+			return nil, errors.New("failed to initialize connection: " + err.Error())
+		}
+	}
+
+	return conn, nil
+}
+
+// Driver implements the database/sql/driver Connector interface.
+func (c *connector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+func execDriverConn(ctx context.Context, conn driver.Conn, query string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, nil)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = conn.Prepare(query)
+	}
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if execer, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = execer.ExecContext(ctx, nil)
+	} else {
+		_, err = stmt.Exec(nil)
+	}
+	return err
+}
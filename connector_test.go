@@ -0,0 +1,121 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestConnector(t *testing.T) {
+	d := &fakeDriver{}
+	c := NewConnector(d,
+		WithGraphPath("g"),
+		WithSearchPath("public", "My Schema"),
+		WithStatementTimeout(1500*time.Millisecond),
+		WithInitStatements("SET application_name = 'ag'"))
+	db := sql.OpenDB(c)
+	defer db.Close()
+
+	if c.Driver() != d {
+		t.Errorf("got %v, want the driver of the base connector", c.Driver())
+	}
+
+	// every new connection is initialized
+	conns := make([]*sql.Conn, 3)
+	for i := range conns {
+		conn, err := db.Conn(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conns[i] = conn
+
+		var path string
+		err = conn.QueryRowContext(context.Background(), `SHOW graph_path`).Scan(&path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path != `"g"` {
+			t.Errorf("got %s, want %s", path, `"g"`)
+		}
+	}
+
+	init := []string{
+		`SET graph_path = "g"`,
+		`SET search_path = "public", "My Schema"`,
+		`SET statement_timeout = 1500`,
+		`SET application_name = 'ag'`,
+	}
+	var want []string
+	for range conns {
+		want = append(want, init...)
+		want = append(want, `SHOW graph_path`)
+	}
+	if got := d.statements(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestConnectorError(t *testing.T) {
+	d := &fakeDriver{}
+	db := sql.OpenDB(NewConnector(d, WithInitStatements("ERROR")))
+	defer db.Close()
+
+	err := db.Ping()
+	if err == nil {
+		t.Fatal("error expected")
+	}
+	if d.conns < 1 || d.closed != d.conns {
+		t.Errorf("%d of %d connections closed", d.closed, d.conns)
+	}
+}
+
+func TestServerConnector(t *testing.T) {
+	skipUnlessServerTest(t)
+
+	base, err := pq.NewConnector("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(NewConnector(base, WithGraphPath(agTestGraphName), WithStatementTimeout(time.Minute)))
+	defer db.Close()
+	db.SetMaxOpenConns(2)
+
+	// both connections have graph_path
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		var path string
+		err = conn.QueryRowContext(ctx, `SHOW graph_path`).Scan(&path)
+		if err != nil {
+			t.Error(err)
+		} else if path != agTestGraphName {
+			t.Errorf("got %s, want %s", path, agTestGraphName)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package cypher builds Cypher queries for AgensGraph without formatting values
+into the query text.
+
+Labels, variables and property keys are quoted as needed, and values are
+emitted as placeholders such as $1. The query and the arguments returned by
+Build can be passed to Query of database/sql as they are.
+
+	n := cypher.Node("n").Label("person")
+	q, args, err := cypher.New().
+		Match(n).
+		Where(cypher.Eq(cypher.Prop("n", "name"), "alice")).
+		Return("n").
+		Build()
+	// MATCH (n:person) WHERE n.name = $1::jsonb RETURN n
+	rows, err := db.Query(q, args...)
+
+Properties of AgensGraph are jsonb. So, a value is encoded in JSON and passed
+as jsonb unless it implements the driver.Valuer interface. Values that
+implement the interface, such as ag.GraphId and ag.Array, are passed as they
+are.
+*/
+package cypher
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+)
+
+// Builder builds a Cypher query clause by clause. The first error that occurs
+// while building the query is reported by Build.
+type Builder struct {
+	buf  strings.Builder
+	args []interface{}
+	err  error
+}
+
+// New returns an empty Builder.
+func New() *Builder {
+	return &Builder{}
+}
+
+// Build returns the query and the arguments for its placeholders.
+func (b *Builder) Build() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	return b.buf.String(), b.args, nil
+}
+
+// String returns the query built so far.
+func (b *Builder) String() string {
+	return b.buf.String()
+}
+
+func (b *Builder) clause(keyword string) {
+	if b.buf.Len() > 0 {
+		b.buf.WriteByte(' ')
+	}
+	b.buf.WriteString(keyword)
+}
+
+func (b *Builder) setError(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Match adds MATCH with patterns.
+func (b *Builder) Match(patterns ...Pattern) *Builder {
+	return b.patternClause("MATCH", patterns)
+}
+
+// OptionalMatch adds OPTIONAL MATCH with patterns.
+func (b *Builder) OptionalMatch(patterns ...Pattern) *Builder {
+	return b.patternClause("OPTIONAL MATCH", patterns)
+}
+
+// Create adds CREATE with patterns.
+func (b *Builder) Create(patterns ...Pattern) *Builder {
+	return b.patternClause("CREATE", patterns)
+}
+
+// Merge adds MERGE with pattern.
+func (b *Builder) Merge(pattern Pattern) *Builder {
+	return b.patternClause("MERGE", []Pattern{pattern})
+}
+
+func (b *Builder) patternClause(keyword string, patterns []Pattern) *Builder {
+	if len(patterns) < 1 {
+		b.setError(errors.New(keyword + " requires a pattern"))
+		return b
+	}
+
+	b.clause(keyword)
+	for i, p := range patterns {
+		if p == nil {
+			b.setError(errors.New(keyword + " requires non-nil patterns"))
+			return b
+		}
+		if i > 0 {
+			b.buf.WriteByte(',')
+		}
+		b.buf.WriteByte(' ')
+		p.writePattern(b)
+	}
+	return b
+}
+
+// Where adds WHERE with cond. Use And to combine conditions.
+func (b *Builder) Where(cond Expr) *Builder {
+	if cond == nil {
+		b.setError(errors.New("WHERE requires a condition"))
+		return b
+	}
+
+	b.clause("WHERE ")
+	cond.writeExpr(b)
+	return b
+}
+
+// Set adds SET with items. See Assign.
+func (b *Builder) Set(items ...SetItem) *Builder {
+	if len(items) < 1 {
+		b.setError(errors.New("SET requires an item"))
+		return b
+	}
+
+	b.clause("SET")
+	for i, item := range items {
+		if item.target == nil {
+			b.setError(errors.New("SET requires items made by Assign"))
+			return b
+		}
+		if i > 0 {
+			b.buf.WriteByte(',')
+		}
+		b.buf.WriteByte(' ')
+		item.target.writeExpr(b)
+		b.buf.WriteString(" = ")
+		item.value.writeExpr(b)
+	}
+	return b
+}
+
+// Remove adds REMOVE with properties.
+func (b *Builder) Remove(props ...PropertyExpr) *Builder {
+	if len(props) < 1 {
+		b.setError(errors.New("REMOVE requires a property"))
+		return b
+	}
+
+	b.clause("REMOVE")
+	for i, p := range props {
+		if i > 0 {
+			b.buf.WriteByte(',')
+		}
+		b.buf.WriteByte(' ')
+		p.writeExpr(b)
+	}
+	return b
+}
+
+// SetChanges adds SET and REMOVE that apply d, which is usually given by
+// ag.Changes, to the properties of variable. A clause is left out if it has
+// nothing to do, so nothing is added if d is empty.
+func (b *Builder) SetChanges(variable string, d ag.PropertiesDiff) *Builder {
+	if len(d.Set) > 0 {
+		items := make([]SetItem, len(d.Set))
+		for i, s := range d.Set {
+			items[i] = Assign(Prop(variable, s.Path...), s.Value)
+		}
+		b.Set(items...)
+	}
+	if len(d.Remove) > 0 {
+		props := make([]PropertyExpr, len(d.Remove))
+		for i, path := range d.Remove {
+			props[i] = Prop(variable, path...)
+		}
+		b.Remove(props...)
+	}
+	return b
+}
+
+// Delete adds DELETE with items. See Return for items.
+func (b *Builder) Delete(items ...interface{}) *Builder {
+	return b.itemClause("DELETE", items)
+}
+
+// DetachDelete adds DETACH DELETE with items. See Return for items.
+func (b *Builder) DetachDelete(items ...interface{}) *Builder {
+	return b.itemClause("DETACH DELETE", items)
+}
+
+// Return adds RETURN with items. An item is either a string, which is the name
+// of a variable, or Expr. Use As to name an item.
+func (b *Builder) Return(items ...interface{}) *Builder {
+	return b.itemClause("RETURN", items)
+}
+
+// ReturnDistinct adds RETURN DISTINCT with items. See Return for items.
+func (b *Builder) ReturnDistinct(items ...interface{}) *Builder {
+	return b.itemClause("RETURN DISTINCT", items)
+}
+
+// With adds WITH with items. See Return for items.
+func (b *Builder) With(items ...interface{}) *Builder {
+	return b.itemClause("WITH", items)
+}
+
+// OrderBy adds ORDER BY with items. See Return for items. Use Asc and Desc
+// to set the order of an item.
+func (b *Builder) OrderBy(items ...interface{}) *Builder {
+	return b.itemClause("ORDER BY", items)
+}
+
+func (b *Builder) itemClause(keyword string, items []interface{}) *Builder {
+	if len(items) < 1 {
+		b.setError(errors.New(keyword + " requires an item"))
+		return b
+	}
+
+	b.clause(keyword)
+	for i, item := range items {
+		if i > 0 {
+			b.buf.WriteByte(',')
+		}
+		b.buf.WriteByte(' ')
+
+		switch item := item.(type) {
+		case string:
+			Var(item).writeExpr(b)
+		case Expr:
+			item.writeExpr(b)
+		default:
+			b.setError(errors.New(keyword + ": invalid item type"))
+		}
+	}
+	return b
+}
+
+// Skip adds SKIP n.
+func (b *Builder) Skip(n int) *Builder {
+	return b.countClause("SKIP", n)
+}
+
+// Limit adds LIMIT n.
+func (b *Builder) Limit(n int) *Builder {
+	return b.countClause("LIMIT", n)
+}
+
+// countClause writes n in the query because an int cannot break the query.
+func (b *Builder) countClause(keyword string, n int) *Builder {
+	if n < 0 {
+		b.setError(errors.New(keyword + " requires a non-negative number"))
+		return b
+	}
+
+	b.clause(keyword)
+	b.buf.WriteByte(' ')
+	b.buf.WriteString(strconv.Itoa(n))
+	return b
+}
+
+// Unwind adds UNWIND list AS alias. list is either Expr or a value which is
+// passed as a parameter.
+func (b *Builder) Unwind(list interface{}, alias string) *Builder {
+	b.clause("UNWIND ")
+	operand(list).writeExpr(b)
+	b.buf.WriteString(" AS ")
+	b.writeIdent(alias)
+	return b
+}
+
+// writeParam writes a placeholder for v and appends v to the arguments.
+func (b *Builder) writeParam(v interface{}) {
+	var arg interface{}
+	jsonb := false
+	switch v := v.(type) {
+	case nil:
+	case driver.Valuer:
+		arg = v
+	default:
+		j, err := json.Marshal(v)
+		if err != nil {
+			b.setError(errors.New("invalid parameter: " + err.Error()))
+			return
+		}
+		arg = string(j)
+		jsonb = true
+	}
+
+	b.args = append(b.args, arg)
+	b.buf.WriteByte('$')
+	b.buf.WriteString(strconv.Itoa(len(b.args)))
+	if jsonb {
+		b.buf.WriteString("::jsonb")
+	}
+}
+
+// writeIdent writes name as an identifier.
+func (b *Builder) writeIdent(name string) {
+	if name == "" {
+		b.setError(errors.New("empty identifier"))
+		return
+	}
+	b.buf.WriteString(QuoteIdent(name))
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cypher
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+)
+
+func TestBuild(t *testing.T) {
+	gid, _ := ag.NewGraphId("3.1")
+	gids := ag.Array([]ag.GraphId{gid})
+
+	n := Node("n").Label("person")
+	m := Node("m").Label("person")
+	r := Rel("r").Label("knows")
+
+	tests := []struct {
+		b     *Builder
+		query string
+		args  []interface{}
+	}{
+		{
+			New().Match(n).Where(Eq(Prop("n", "name"), "alice")).Return("n"),
+			`MATCH (n:person) WHERE n.name = $1::jsonb RETURN n`,
+			[]interface{}{`"alice"`},
+		},
+		{
+			New().Match(Path(n).Out(r, m)).Where(Eq(Func("id", Var("n")), gid)).Return(Prop("m", "name")),
+			`MATCH (n:person)-[r:knows]->(m:person) WHERE id(n) = $1 RETURN m.name`,
+			[]interface{}{gid},
+		},
+		{
+			New().Match(n).Where(In(Func("id", Var("n")), gids)).Return(Count()),
+			`MATCH (n:person) WHERE id(n) IN $1 RETURN count(*)`,
+			[]interface{}{gids},
+		},
+		{
+			New().Create(Node("").Label("Person").Props(Props{"name": "bob", "age": 30})),
+			`CREATE (:"Person" {age: $1::jsonb, name: $2::jsonb})`,
+			[]interface{}{`30`, `"bob"`},
+		},
+		{
+			New().Match(n).OptionalMatch(Path(n).In(Rel("").Hops(1, 3), Node("x"))).Return("n", As(Func("count", Var("x")), "c")),
+			`MATCH (n:person) OPTIONAL MATCH (n:person)<-[*1..3]-(x) RETURN n, count(x) AS c`,
+			nil,
+		},
+		{
+			New().Match(Path(Node("a")).Both(Rel("").Hops(2, -1), Node("b")).Named("p")).Return("p"),
+			`MATCH p = (a)-[*2..]-(b) RETURN p`,
+			nil,
+		},
+		{
+			New().Merge(Node("n").Label("city").Props(Props{"name": "Seoul"})).Set(Assign(Prop("n", "visited"), true)),
+			`MERGE (n:city {name: $1::jsonb}) SET n.visited = $2::jsonb`,
+			[]interface{}{`"Seoul"`, `true`},
+		},
+		{
+			New().Match(n).Remove(Prop("n", "age"), Prop("n", "address", "city")),
+			`MATCH (n:person) REMOVE n.age, n.address.city`,
+			nil,
+		},
+		{
+			New().Match(n).SetChanges("n", ag.PropertiesDiff{
+				Set:    []ag.PropertySet{{Path: []string{"age"}, Value: []byte(`30`)}, {Path: []string{"address", "city"}, Value: []byte(`"Seoul"`)}},
+				Remove: [][]string{{"nick"}},
+			}).SetChanges("n", ag.PropertiesDiff{}),
+			`MATCH (n:person) SET n.age = $1::jsonb, n.address.city = $2::jsonb REMOVE n.nick`,
+			[]interface{}{`30`, `"Seoul"`},
+		},
+		{
+			New().Match(Path(n).Out(r, m)).Delete("r").DetachDelete("m"),
+			`MATCH (n:person)-[r:knows]->(m:person) DELETE r DETACH DELETE m`,
+			nil,
+		},
+		{
+			New().Unwind([]string{"a", "b"}, "x").Create(Node("").Label("tag").Props(Props{"name": Var("x")})),
+			`UNWIND $1::jsonb AS x CREATE (:tag {name: x})`,
+			[]interface{}{`["a","b"]`},
+		},
+		{
+			New().Match(n).With("n").Where(And(Ge(Prop("n", "age"), 20), Or(StartsWith(Prop("n", "name"), "a"), IsNull(Prop("n", "nick"))))).
+				ReturnDistinct("n").OrderBy(Desc(Prop("n", "age")), Asc("n")).Skip(10).Limit(5),
+			`MATCH (n:person) WITH n WHERE (n.age >= $1::jsonb AND (n.name STARTS WITH $2::jsonb OR n.nick IS NULL)) RETURN DISTINCT n ORDER BY n.age DESC, n ASC SKIP 10 LIMIT 5`,
+			[]interface{}{`20`, `"a"`},
+		},
+		{
+			New().Match(Node("n").Label(`a"b`)).Where(Not(Eq(Prop("n", "select"), nil))).Return("n"),
+			`MATCH (n:"a""b") WHERE NOT (n."select" = $1) RETURN n`,
+			[]interface{}{nil},
+		},
+	}
+	for _, c := range tests {
+		q, args, err := c.b.Build()
+		if err != nil {
+			t.Errorf("%s: %s", c.query, err)
+			continue
+		}
+		if q != c.query {
+			t.Errorf("got %s, want %s", q, c.query)
+		}
+		if !reflect.DeepEqual(args, c.args) {
+			t.Errorf("got %#v, want %#v", args, c.args)
+		}
+	}
+}
+
+func TestBuildError(t *testing.T) {
+	tests := []*Builder{
+		New().Match(),
+		New().Match(Node("")).Where(nil),
+		New().Match(Node("n")).Return(1),
+		New().Match(Node("n")).Return(""),
+		New().Match(Node("n")).Where(And()),
+		New().Match(Node("n")).Where(Eq(Func("x)", Var("n")), 1)),
+		New().Match(Node("n")).Where(Eq(Prop("n"), 1)),
+		New().Match(Node("n")).Where(Eq(Prop("n", "x"), func() {})),
+		New().Match(Path(Node("a")).Out(Rel("").Hops(3, 1), Node("b"))),
+		New().Match(Node("n")).Limit(-1),
+		New().Match(Node("n")).Where(Not(nil)),
+		New().Match(Node("n")).Where(Or(Eq(Var("n"), 1), nil)),
+		New().Match(Node("n")).Set(SetItem{}),
+		New().Match(nil),
+		New().Match(Node("n")).Set(Assign(Prop("n", "x"), 1), SetItem{}),
+	}
+	for _, b := range tests {
+		_, _, err := b.Build()
+		if err == nil {
+			t.Errorf("error expected for %s", b)
+		}
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"person", "person"},
+		{"_x1", "_x1"},
+		{"Person", `"Person"`},
+		{"1x", `"1x"`},
+		{"a b", `"a b"`},
+		{`a"b`, `"a""b"`},
+		{"match", `"match"`},
+		{"사람", `"사람"`},
+		{"", `""`},
+	}
+	for _, c := range tests {
+		if got := QuoteIdent(c.name); got != c.want {
+			t.Errorf("got %s, want %s", got, c.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cypher_test
+
+import (
+	"fmt"
+
+	"github.com/bitnine-oss/agensgraph-golang/cypher"
+)
+
+func Example() {
+	a := cypher.Node("a").Label("person")
+	b := cypher.Node("b").Label("person")
+	knows := cypher.Rel("").Label("knows")
+
+	q, args, err := cypher.New().
+		Match(cypher.Path(a).Out(knows, b)).
+		Where(cypher.And(
+			cypher.Eq(cypher.Prop("a", "name"), "alice"),
+			cypher.Gt(cypher.Prop("b", "age"), 20),
+		)).
+		Return(cypher.As(cypher.Prop("b", "name"), "name")).
+		OrderBy(cypher.Asc("name")).
+		Limit(10).
+		Build()
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(q)
+	fmt.Println(args...)
+	// Output:
+	// MATCH (a:person)-[:knows]->(b:person) WHERE (a.name = $1::jsonb AND b.age > $2::jsonb) RETURN b.name AS name ORDER BY name ASC LIMIT 10
+	// "alice" 20
+}
@@ -0,0 +1,312 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cypher
+
+import "errors"
+
+// Expr is an interface used by WHERE, SET, RETURN and so on to write an
+// expression.
+//
+// The functions that build Expr take operands of type interface{}. An operand
+// that is not Expr is a value and passed as a parameter even if it is a
+// string. Use Var to refer to a variable.
+type Expr interface {
+	writeExpr(b *Builder)
+}
+
+func operand(v interface{}) Expr {
+	if e, ok := v.(Expr); ok {
+		return e
+	}
+	return paramExpr{v}
+}
+
+type varExpr string
+
+// Var returns a reference to variable name.
+func Var(name string) Expr {
+	return varExpr(name)
+}
+
+func (e varExpr) writeExpr(b *Builder) {
+	b.writeIdent(string(e))
+}
+
+// PropertyExpr is a property of a variable such as n.name.
+type PropertyExpr struct {
+	variable string
+	keys     []string
+}
+
+// Prop returns the property of variable at keys. Multiple keys refer to a
+// nested property such as n.address.city.
+func Prop(variable string, keys ...string) PropertyExpr {
+	return PropertyExpr{variable, keys}
+}
+
+func (e PropertyExpr) writeExpr(b *Builder) {
+	if len(e.keys) < 1 {
+		b.setError(errors.New("property requires a key"))
+		return
+	}
+
+	b.writeIdent(e.variable)
+	for _, k := range e.keys {
+		b.buf.WriteByte('.')
+		b.writeIdent(k)
+	}
+}
+
+type paramExpr struct {
+	v interface{}
+}
+
+// Param returns a placeholder for v. It is rarely needed because values are
+// passed as parameters by default.
+func Param(v interface{}) Expr {
+	return paramExpr{v}
+}
+
+func (e paramExpr) writeExpr(b *Builder) {
+	b.writeParam(e.v)
+}
+
+type funcExpr struct {
+	name string
+	args []interface{}
+}
+
+// Func returns a call of function name with args, such as id(n). name must
+// consist of ASCII letters, digits and underscores only.
+func Func(name string, args ...interface{}) Expr {
+	return funcExpr{name, args}
+}
+
+func (e funcExpr) writeExpr(b *Builder) {
+	if !isFuncName(e.name) {
+		b.setError(errors.New("invalid function name: " + e.name))
+		return
+	}
+
+	b.buf.WriteString(e.name)
+	b.buf.WriteByte('(')
+	for i, arg := range e.args {
+		if i > 0 {
+			b.buf.WriteString(", ")
+		}
+		operand(arg).writeExpr(b)
+	}
+	b.buf.WriteByte(')')
+}
+
+func isFuncName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns count(*).
+func Count() Expr {
+	return countAllExpr{}
+}
+
+type countAllExpr struct{}
+
+func (countAllExpr) writeExpr(b *Builder) {
+	b.buf.WriteString("count(*)")
+}
+
+type binaryExpr struct {
+	op   string
+	x, y interface{}
+}
+
+func (e binaryExpr) writeExpr(b *Builder) {
+	operand(e.x).writeExpr(b)
+	b.buf.WriteByte(' ')
+	b.buf.WriteString(e.op)
+	b.buf.WriteByte(' ')
+	operand(e.y).writeExpr(b)
+}
+
+// Eq returns x = y.
+func Eq(x, y interface{}) Expr { return binaryExpr{"=", x, y} }
+
+// Ne returns x <> y.
+func Ne(x, y interface{}) Expr { return binaryExpr{"<>", x, y} }
+
+// Lt returns x < y.
+func Lt(x, y interface{}) Expr { return binaryExpr{"<", x, y} }
+
+// Le returns x <= y.
+func Le(x, y interface{}) Expr { return binaryExpr{"<=", x, y} }
+
+// Gt returns x > y.
+func Gt(x, y interface{}) Expr { return binaryExpr{">", x, y} }
+
+// Ge returns x >= y.
+func Ge(x, y interface{}) Expr { return binaryExpr{">=", x, y} }
+
+// In returns x IN list.
+func In(x, list interface{}) Expr { return binaryExpr{"IN", x, list} }
+
+// StartsWith returns x STARTS WITH y.
+func StartsWith(x, y interface{}) Expr { return binaryExpr{"STARTS WITH", x, y} }
+
+// EndsWith returns x ENDS WITH y.
+func EndsWith(x, y interface{}) Expr { return binaryExpr{"ENDS WITH", x, y} }
+
+// Contains returns x CONTAINS y.
+func Contains(x, y interface{}) Expr { return binaryExpr{"CONTAINS", x, y} }
+
+type postfixExpr struct {
+	op string
+	x  interface{}
+}
+
+func (e postfixExpr) writeExpr(b *Builder) {
+	operand(e.x).writeExpr(b)
+	b.buf.WriteByte(' ')
+	b.buf.WriteString(e.op)
+}
+
+// IsNull returns x IS NULL.
+func IsNull(x interface{}) Expr { return postfixExpr{"IS NULL", x} }
+
+// IsNotNull returns x IS NOT NULL.
+func IsNotNull(x interface{}) Expr { return postfixExpr{"IS NOT NULL", x} }
+
+type logicalExpr struct {
+	op    string
+	conds []Expr
+}
+
+// And returns the conjunction of conds.
+func And(conds ...Expr) Expr { return logicalExpr{"AND", conds} }
+
+// Or returns the disjunction of conds.
+func Or(conds ...Expr) Expr { return logicalExpr{"OR", conds} }
+
+func (e logicalExpr) writeExpr(b *Builder) {
+	if len(e.conds) < 1 {
+		b.setError(errors.New(e.op + " requires a condition"))
+		return
+	}
+	for _, c := range e.conds {
+		if c == nil {
+			b.setError(errors.New(e.op + " requires non-nil conditions"))
+			return
+		}
+	}
+	if len(e.conds) == 1 {
+		e.conds[0].writeExpr(b)
+		return
+	}
+
+	b.buf.WriteByte('(')
+	for i, c := range e.conds {
+		if i > 0 {
+			b.buf.WriteByte(' ')
+			b.buf.WriteString(e.op)
+			b.buf.WriteByte(' ')
+		}
+		c.writeExpr(b)
+	}
+	b.buf.WriteByte(')')
+}
+
+type notExpr struct {
+	cond Expr
+}
+
+// Not returns the negation of cond.
+func Not(cond Expr) Expr { return notExpr{cond} }
+
+func (e notExpr) writeExpr(b *Builder) {
+	if e.cond == nil {
+		b.setError(errors.New("NOT requires a condition"))
+		return
+	}
+
+	b.buf.WriteString("NOT (")
+	e.cond.writeExpr(b)
+	b.buf.WriteByte(')')
+}
+
+type aliasExpr struct {
+	x     interface{}
+	alias string
+}
+
+// As returns x AS alias for RETURN and WITH. If x is a string, it is the name
+// of a variable.
+func As(x interface{}, alias string) Expr { return aliasExpr{x, alias} }
+
+func (e aliasExpr) writeExpr(b *Builder) {
+	item(e.x).writeExpr(b)
+	b.buf.WriteString(" AS ")
+	b.writeIdent(e.alias)
+}
+
+type orderExpr struct {
+	x   interface{}
+	dir string
+}
+
+// Asc returns x ASC for ORDER BY. If x is a string, it is the name of a
+// variable.
+func Asc(x interface{}) Expr { return orderExpr{x, "ASC"} }
+
+// Desc returns x DESC for ORDER BY. If x is a string, it is the name of a
+// variable.
+func Desc(x interface{}) Expr { return orderExpr{x, "DESC"} }
+
+func (e orderExpr) writeExpr(b *Builder) {
+	item(e.x).writeExpr(b)
+	b.buf.WriteByte(' ')
+	b.buf.WriteString(e.dir)
+}
+
+// item is operand for RETURN, WITH and so on where a string is the name of a
+// variable.
+func item(v interface{}) Expr {
+	if s, ok := v.(string); ok {
+		return Var(s)
+	}
+	return operand(v)
+}
+
+// SetItem is an item of SET. Use Assign to make one; the zero value is
+// invalid.
+type SetItem struct {
+	target Expr
+	value  Expr
+}
+
+// Assign returns SetItem that sets prop to value.
+func Assign(prop PropertyExpr, value interface{}) SetItem {
+	return SetItem{prop, operand(value)}
+}
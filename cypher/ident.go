@@ -0,0 +1,75 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cypher
+
+import "strings"
+
+// QuoteIdent returns name as an identifier of AgensGraph. name is
+// double-quoted unless it consists of lower case ASCII letters, digits and
+// underscores only, does not start with a digit, and is not a keyword.
+//
+// Identifiers are case-sensitive once quoted; "Person" and person, which is
+// folded to lower case by the server, are different labels.
+func QuoteIdent(name string) string {
+	if !needsQuote(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func needsQuote(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return true
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' {
+			return true
+		}
+	}
+	return keywords[name]
+}
+
+// keywords of Cypher and reserved keywords of SQL that cannot be used as an
+// identifier without quotes
+var keywords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true,
+	"array": true, "as": true, "asc": true, "ascending": true,
+	"asymmetric": true, "both": true, "by": true, "call": true, "case": true,
+	"cast": true, "check": true, "collate": true, "column": true,
+	"constraint": true, "contains": true, "create": true,
+	"current_catalog": true, "current_date": true, "current_role": true,
+	"current_time": true, "current_timestamp": true, "current_user": true,
+	"default": true, "deferrable": true, "delete": true, "desc": true,
+	"descending": true, "detach": true, "distinct": true, "do": true,
+	"else": true, "end": true, "ends": true, "except": true, "exists": true,
+	"false": true, "fetch": true, "for": true, "foreign": true, "from": true,
+	"grant": true, "group": true, "having": true, "in": true,
+	"initially": true, "intersect": true, "into": true, "is": true,
+	"lateral": true, "leading": true, "limit": true, "load": true,
+	"localtime": true, "localtimestamp": true, "match": true, "merge": true,
+	"not": true, "null": true, "offset": true, "on": true, "only": true,
+	"optional": true, "or": true, "order": true, "placing": true,
+	"primary": true, "references": true, "remove": true, "return": true,
+	"returning": true, "select": true, "session_user": true, "set": true,
+	"size": true, "skip": true, "some": true, "starts": true,
+	"symmetric": true, "table": true, "then": true, "to": true,
+	"trailing": true, "true": true, "union": true, "unique": true,
+	"unwind": true, "user": true, "using": true, "variadic": true,
+	"when": true, "where": true, "window": true, "with": true, "xor": true,
+	"yield": true,
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cypher
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+)
+
+// Pattern is an interface used by MATCH, OPTIONAL MATCH, CREATE and MERGE.
+// NodePattern and PathPattern implement it.
+type Pattern interface {
+	writePattern(b *Builder)
+}
+
+// Props is a map of properties in a pattern. Values are passed as parameters
+// and keys are written in sorted order.
+type Props map[string]interface{}
+
+func (p Props) write(b *Builder) {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b.buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.buf.WriteString(", ")
+		}
+		b.writeIdent(k)
+		b.buf.WriteString(": ")
+		operand(p[k]).writeExpr(b)
+	}
+	b.buf.WriteByte('}')
+}
+
+// NodePattern is a pattern of a vertex such as (n:person {name: $1}). The
+// methods return a modified copy of the pattern.
+type NodePattern struct {
+	variable string
+	label    string
+	props    Props
+}
+
+// Node returns NodePattern whose variable is variable. variable may be empty.
+func Node(variable string) NodePattern {
+	return NodePattern{variable: variable}
+}
+
+// Label returns a copy of n whose label is label.
+func (n NodePattern) Label(label string) NodePattern {
+	n.label = label
+	return n
+}
+
+// Props returns a copy of n whose properties are props.
+func (n NodePattern) Props(props Props) NodePattern {
+	n.props = props
+	return n
+}
+
+func (n NodePattern) writePattern(b *Builder) {
+	b.buf.WriteByte('(')
+	writeElement(b, n.variable, n.label, n.props)
+	b.buf.WriteByte(')')
+}
+
+func writeElement(b *Builder, variable, label string, props Props) {
+	if variable != "" {
+		b.writeIdent(variable)
+	}
+	if label != "" {
+		b.buf.WriteByte(':')
+		b.writeIdent(label)
+	}
+	if props != nil {
+		if variable != "" || label != "" {
+			b.buf.WriteByte(' ')
+		}
+		props.write(b)
+	}
+}
+
+// RelPattern is a pattern of an edge such as [r:knows*1..3]. The methods
+// return a modified copy of the pattern.
+type RelPattern struct {
+	variable string
+	label    string
+	props    Props
+
+	varLength bool
+	minHops   int
+	maxHops   int
+}
+
+// Rel returns RelPattern whose variable is variable. variable may be empty.
+func Rel(variable string) RelPattern {
+	return RelPattern{variable: variable}
+}
+
+// Label returns a copy of r whose label is label.
+func (r RelPattern) Label(label string) RelPattern {
+	r.label = label
+	return r
+}
+
+// Props returns a copy of r whose properties are props.
+func (r RelPattern) Props(props Props) RelPattern {
+	r.props = props
+	return r
+}
+
+// Hops returns a copy of r which matches paths of variable length between min
+// and max. A negative min or max leaves the bound open.
+func (r RelPattern) Hops(min, max int) RelPattern {
+	r.varLength, r.minHops, r.maxHops = true, min, max
+	return r
+}
+
+func (r RelPattern) write(b *Builder) {
+	b.buf.WriteByte('[')
+	if r.variable != "" {
+		b.writeIdent(r.variable)
+	}
+	if r.label != "" {
+		b.buf.WriteByte(':')
+		b.writeIdent(r.label)
+	}
+	if r.varLength {
+		if r.minHops >= 0 && r.maxHops >= 0 && r.minHops > r.maxHops {
+			b.setError(errors.New("invalid hops: min is greater than max"))
+		}
+		b.buf.WriteByte('*')
+		if r.minHops >= 0 {
+			b.buf.WriteString(strconv.Itoa(r.minHops))
+		}
+		b.buf.WriteString("..")
+		if r.maxHops >= 0 {
+			b.buf.WriteString(strconv.Itoa(r.maxHops))
+		}
+	}
+	if r.props != nil {
+		b.buf.WriteByte(' ')
+		r.props.write(b)
+	}
+	b.buf.WriteByte(']')
+}
+
+// Direction is the direction of a relationship in a path pattern.
+type Direction int
+
+const (
+	// Outgoing is (a)-[r]->(b).
+	Outgoing Direction = iota
+	// Incoming is (a)<-[r]-(b).
+	Incoming
+	// Undirected is (a)-[r]-(b).
+	Undirected
+)
+
+type step struct {
+	dir  Direction
+	rel  RelPattern
+	node NodePattern
+}
+
+// PathPattern is a pattern of a path that starts with a vertex. The methods
+// return a modified copy of the pattern.
+type PathPattern struct {
+	name  string
+	start NodePattern
+	steps []step
+}
+
+// Path returns PathPattern that starts with start.
+func Path(start NodePattern) PathPattern {
+	return PathPattern{start: start}
+}
+
+// Named returns a copy of p that is assigned to variable name as in
+// p = (a)-[r]->(b).
+func (p PathPattern) Named(name string) PathPattern {
+	p.name = name
+	return p
+}
+
+// Out returns a copy of p followed by (...)-[r]->(n).
+func (p PathPattern) Out(r RelPattern, n NodePattern) PathPattern {
+	return p.Step(Outgoing, r, n)
+}
+
+// In returns a copy of p followed by (...)<-[r]-(n).
+func (p PathPattern) In(r RelPattern, n NodePattern) PathPattern {
+	return p.Step(Incoming, r, n)
+}
+
+// Both returns a copy of p followed by (...)-[r]-(n).
+func (p PathPattern) Both(r RelPattern, n NodePattern) PathPattern {
+	return p.Step(Undirected, r, n)
+}
+
+// Step returns a copy of p followed by r in direction dir and n.
+func (p PathPattern) Step(dir Direction, r RelPattern, n NodePattern) PathPattern {
+	steps := make([]step, len(p.steps), len(p.steps)+1)
+	copy(steps, p.steps)
+	p.steps = append(steps, step{dir, r, n})
+	return p
+}
+
+func (p PathPattern) writePattern(b *Builder) {
+	if p.name != "" {
+		b.writeIdent(p.name)
+		b.buf.WriteString(" = ")
+	}
+
+	p.start.writePattern(b)
+	for _, s := range p.steps {
+		switch s.dir {
+		case Outgoing:
+			b.buf.WriteByte('-')
+			s.rel.write(b)
+			b.buf.WriteString("->")
+		case Incoming:
+			b.buf.WriteString("<-")
+			s.rel.write(b)
+			b.buf.WriteByte('-')
+		case Undirected:
+			b.buf.WriteByte('-')
+			s.rel.write(b)
+			b.buf.WriteByte('-')
+		default:
+			b.setError(errors.New("invalid direction: " + strconv.Itoa(int(s.dir))))
+		}
+		s.node.writePattern(b)
+	}
+}
@@ -17,9 +17,15 @@ limitations under the License.
 package ag_test
 
 import (
+	"context"
 	"database/sql"
+	"log"
+	"slices"
+	"time"
 
 	"github.com/bitnine-oss/agensgraph-golang"
+	"github.com/bitnine-oss/agensgraph-golang/cypher"
+	"github.com/lib/pq"
 )
 
 var db *sql.DB
@@ -62,14 +68,65 @@ func ExampleBasicVertex_Scan() {
 	}
 }
 
+func ExampleBasicVertex_Value() {
+	var v ag.BasicVertex
+	db.QueryRow(`MATCH (n) RETURN n LIMIT 1`).Scan(&v)
+	db.QueryRow(`MATCH (n) WHERE id(n) = id($1) RETURN n`, v)
+}
+
 func ExampleArray_basicVertex() {
 	var vs []ag.BasicVertex
+
+	// Scan()
 	err := db.QueryRow(`MATCH p=(:v)-[:e]->(:v) RETURN nodes(p) LIMIT 1`).Scan(ag.Array(&vs))
 	if err == nil && vs != nil {
 		// Valid _vertex
 	} else {
 		// An error occurred or the _vertex is NULL
 	}
+
+	// Value()
+	db.Query(`UNWIND $1 AS v RETURN v`, ag.Array(vs))
+}
+
+func ExampleTypedVertex_Scan() {
+	type person struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	var v ag.TypedVertex[person]
+	err := db.QueryRow(`MATCH (n:person) RETURN n LIMIT 1`).Scan(&v)
+	if err == nil && v.Valid {
+		// v.Properties.Name and v.Properties.Age are available
+	} else {
+		// An error occurred or the vertex is NULL
+	}
+
+	var vs []ag.TypedVertex[person]
+	db.QueryRow(`MATCH p=(:person)-[:knows]->(:person) RETURN nodes(p) LIMIT 1`).Scan(ag.Array(&vs))
+}
+
+func ExampleScanEach() {
+	rows, err := db.Query(`MATCH (n:v) RETURN collect(n)`)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var raw sql.RawBytes
+		rows.Scan(&raw)
+
+		var v ag.BasicVertex
+		ag.ScanEach([]byte(raw), &v, func(i int) error {
+			// v holds the i-th vertex
+			if i == 99 {
+				return ag.ErrStopScan
+			}
+			return nil
+		})
+	}
 }
 
 func ExampleBasicEdge_Scan() {
@@ -101,3 +158,113 @@ func ExampleBasicPath_Scan() {
 		// An error occurred or the graphpath is NULL
 	}
 }
+
+func ExampleBasicPath_Value() {
+	var p ag.BasicPath
+	db.QueryRow(`MATCH p=(:v)-[:e]->(:v) RETURN p LIMIT 1`).Scan(&p)
+	db.QueryRow(`SELECT $1::graphpath`, p)
+}
+
+func ExampleTypedPath_Scan() {
+	type person struct {
+		Name string `json:"name"`
+	}
+	type knows struct {
+		Since int `json:"since"`
+	}
+
+	var p ag.TypedPath[ag.TypedVertex[person], *ag.TypedVertex[person], ag.TypedEdge[knows], *ag.TypedEdge[knows]]
+	err := db.QueryRow(`MATCH p=(:person)-[:knows]->(:person) RETURN p LIMIT 1`).Scan(&p)
+	if err == nil && p.Valid {
+		// Valid graphpath
+	} else {
+		// An error occurred or the graphpath is NULL
+	}
+}
+
+func ExampleNewConnector() {
+	base, err := pq.NewConnector("")
+	if err != nil {
+		return
+	}
+
+	// every pooled connection has the same graph_path and statement_timeout
+	db := sql.OpenDB(ag.NewConnector(base,
+		ag.WithGraphPath("g"),
+		ag.WithStatementTimeout(30*time.Second)))
+	defer db.Close()
+
+	db.QueryRow(`MATCH (n) RETURN n LIMIT 1`)
+}
+
+func ExampleGraphId_LabelName() {
+	// load the labels once and share reg
+	reg := ag.NewLabelRegistry(db, "g")
+
+	var gid ag.GraphId
+	err := db.QueryRow(`MATCH (n) RETURN id(n) LIMIT 1`).Scan(&gid)
+	if err != nil {
+		return
+	}
+
+	name, err := gid.LabelName(reg)
+	if err == nil {
+		// name is the label of the vertex
+		_ = name
+	}
+}
+
+func ExampleChanges() {
+	var v ag.TrackedVertex
+	err := db.QueryRow(`MATCH (n:person) RETURN n LIMIT 1`).Scan(&v)
+	if err != nil {
+		return
+	}
+
+	v.Properties["age"] = 30
+	delete(v.Properties, "nickname")
+
+	d, err := ag.Changes(v)
+	if err != nil || d.Empty() {
+		return
+	}
+
+	// MATCH (n) WHERE id(n) = $1 SET n.age = $2::jsonb REMOVE n.nickname
+	q, args, err := cypher.New().Match(cypher.Node("n")).
+		Where(cypher.Eq(cypher.Func("id", cypher.Var("n")), v.Id)).
+		SetChanges("n", d).
+		Build()
+	if err != nil {
+		return
+	}
+	db.Exec(q, args...)
+}
+
+func ExampleBulkLoader() {
+	base, err := pq.NewConnector("")
+	if err != nil {
+		return
+	}
+	g := ag.NewGraph(base, "g")
+	defer g.Close()
+
+	l := ag.NewBulkLoader(g)
+	l.CreateLabels = true
+	l.Progress = func(loaded int) {
+		log.Printf("%d vertices loaded", loaded)
+	}
+
+	vs := make([]ag.BasicVertex, 2)
+	for i, name := range []string{"alice", "bob"} {
+		vs[i].Label = "person"
+		vs[i].Properties = map[string]interface{}{"name": name}
+	}
+	ids, err := l.LoadVertices(context.Background(), slices.Values(vs))
+	if err != nil {
+		return
+	}
+
+	var e ag.BasicEdge
+	e.Label, e.Start, e.End = "knows", ids[0], ids[1]
+	l.LoadEdges(context.Background(), slices.Values([]ag.BasicEdge{e}))
+}
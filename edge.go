@@ -22,7 +22,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"regexp"
 )
 
 // Edge gives any struct an ability to read the value from the database
@@ -37,15 +36,13 @@ type EdgeCore struct {
 	End   GraphId
 }
 
-var edgeCoreRegexp = regexp.MustCompile(`^(.+?)\[(\d+\.\d+)\]\[(\d+\.\d+),(\d+\.\d+)\]`)
-
 func (_ Edge) readEntity(b []byte) (*entityData, error) {
-	m := edgeCoreRegexp.FindSubmatch(b)
-	if m == nil {
+	label, id, start, end, advance := scanEdgeCore(b)
+	if advance < 1 {
 		return nil, fmt.Errorf("bad edge representation: %s", b)
 	}
 
-	return makeEdgeData(m[1], m[2], m[3], m[4], b[len(m[0]):])
+	return makeEdgeData(label, id, start, end, b[advance:])
 }
 
 func makeEdgeData(label, id, start, end, props []byte) (*entityData, error) {
@@ -53,17 +50,22 @@ func makeEdgeData(label, id, start, end, props []byte) (*entityData, error) {
 
 	c.Label = string(label)
 
-	err := c.Id.Scan(id)
+	// copy all the IDs at once
+	ni, ns, ne := len(id), len(start), len(end)
+	ids := make([]byte, 0, ni+ns+ne)
+	ids = append(append(append(ids, id...), start...), end...)
+
+	err := c.Id.setBytes(ids[:ni:ni])
 	if err != nil {
 		return nil, errors.New("invalid edge ID: " + err.Error())
 	}
 
-	err = c.Start.Scan(start)
+	err = c.Start.setBytes(ids[ni : ni+ns : ni+ns])
 	if err != nil {
 		return nil, errors.New("invalid edge start ID: " + err.Error())
 	}
 
-	err = c.End.Scan(end)
+	err = c.End.setBytes(ids[ni+ns:])
 	if err != nil {
 		return nil, errors.New("invalid edge end ID: " + err.Error())
 	}
@@ -71,35 +73,40 @@ func makeEdgeData(label, id, start, end, props []byte) (*entityData, error) {
 	return &entityData{c, props}, nil
 }
 
+func appendEdgeCore(b []byte, c EdgeCore) ([]byte, error) {
+	if c.Label == "" {
+		return nil, errors.New("invalid edge label: empty")
+	}
+	if !c.Id.Valid {
+		return nil, errors.New("invalid edge ID: NULL")
+	}
+	if !c.Start.Valid {
+		return nil, errors.New("invalid edge start ID: NULL")
+	}
+	if !c.End.Valid {
+		return nil, errors.New("invalid edge end ID: NULL")
+	}
+
+	b = appendLabel(b, c.Label)
+	b = append(b, '[')
+	b = c.Id.appendText(b)
+	b = append(b, ']', '[')
+	b = c.Start.appendText(b)
+	b = append(b, ',')
+	b = c.End.appendText(b)
+	return append(b, ']'), nil
+}
+
 func (_ Edge) readElements(b []byte) ([]interface{}, error) {
 	return readEdgeElements(b)
 }
 
-func readEdgeElements(b []byte) ([]interface{}, error) {
-	// remove surrounding brackets
-	b = b[1 : len(b)-1]
-
-	var ds []interface{}
-	for len(b) > 0 {
-		if len(ds) > 0 {
-			// remove comma
-			b = b[1:]
-		}
-
-		advance, data, err := readEdgeElement(b)
-		if err != nil {
-			return nil, err
-		}
-		if data == nil {
-			ds = append(ds, nil)
-		} else {
-			ds = append(ds, data)
-		}
-
-		b = b[advance:]
-	}
+func (_ Edge) readElement(b []byte) (int, *entityData, error) {
+	return readEdgeElement(b)
+}
 
-	return ds, nil
+func readEdgeElements(b []byte) ([]interface{}, error) {
+	return readElements(b, readEdgeElement)
 }
 
 func readEdgeElement(b []byte) (advance int, data *entityData, err error) {
@@ -108,12 +115,11 @@ func readEdgeElement(b []byte) (advance int, data *entityData, err error) {
 		return
 	}
 
-	m := edgeCoreRegexp.FindSubmatch(b)
-	if m == nil {
+	label, id, start, end, advance := scanEdgeCore(b)
+	if advance < 1 {
 		err = fmt.Errorf("bad edge representation: %s", b)
 		return
 	}
-	advance = len(m[0])
 
 	props, err := readJSONObject(b[advance:])
 	if err != nil {
@@ -122,16 +128,17 @@ func readEdgeElement(b []byte) (advance int, data *entityData, err error) {
 	}
 	advance += len(props)
 
-	data, err = makeEdgeData(m[1], m[2], m[3], m[4], props)
+	data, err = makeEdgeData(label, id, start, end, props)
 	return
 }
 
 // EdgeHeader may be used as an embedded field of any struct to change the
-// struct to an entity for edge.
+// struct to an entity for edge. Its fields are not properties, so
+// encoding/json skips them.
 type EdgeHeader struct {
 	Edge
-	Valid    bool // Valid is true if the edge is not NULL
-	EdgeCore      // EdgeCore is valid only if Valid is true
+	Valid    bool       `json:"-"` // Valid is true if the edge is not NULL
+	EdgeCore `json:"-"` // EdgeCore is valid only if Valid is true
 }
 
 // SaveEntity implements EntitySaver interface.
@@ -151,6 +158,11 @@ func (h *EdgeHeader) SaveEntity(valid bool, core interface{}) error {
 	return nil
 }
 
+// LoadEntity implements EntityLoader interface.
+func (h EdgeHeader) LoadEntity() (valid bool, core interface{}) {
+	return h.Valid, h.EdgeCore
+}
+
 // BasicEdge can be used to scan the value from the database driver as an edge.
 //
 // This is a reference implementation of an entity for edge using all the basic
@@ -163,8 +175,8 @@ type BasicEdge struct {
 
 func (e BasicEdge) String() string {
 	if e.Valid {
-		p, _ := json.Marshal(e.Properties)
-		return fmt.Sprintf("%s[%s][%s,%s]%s", e.Label, e.Id, e.Start, e.End, p)
+		p, _ := e.LoadProperties()
+		return fmt.Sprintf("%s[%s][%s,%s]%s", quoteLabel(e.Label), e.Id, e.Start, e.End, p)
 	} else {
 		return "NULL"
 	}
@@ -173,6 +185,7 @@ func (e BasicEdge) String() string {
 // SaveProperties implements PropertiesSaver interface. It calls json.Unmarshal
 // to unmarshal b and store the result in Properties.
 func (e *BasicEdge) SaveProperties(b []byte) error {
+	e.Properties = nil
 	err := json.Unmarshal(b, &e.Properties)
 	if err != nil {
 		return errors.New("invalid edge properties: " + err.Error())
@@ -180,11 +193,26 @@ func (e *BasicEdge) SaveProperties(b []byte) error {
 	return nil
 }
 
+// LoadProperties implements PropertiesLoader interface. It calls json.Marshal
+// to marshal Properties.
+func (e BasicEdge) LoadProperties() ([]byte, error) {
+	if e.Properties == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(e.Properties)
+}
+
 // Scan implements the database/sql Scanner interface. It calls ScanEntity.
 func (e *BasicEdge) Scan(src interface{}) error {
 	return ScanEntity(src, e)
 }
 
+// Value implements the database/sql/driver Valuer interface. It calls
+// ValueEntity.
+func (e BasicEdge) Value() (driver.Value, error) {
+	return ValueEntity(e)
+}
+
 type basicEdgeArray []BasicEdge
 
 func (a *basicEdgeArray) Scan(src interface{}) error {
@@ -219,5 +247,59 @@ func (a *basicEdgeArray) Scan(src interface{}) error {
 }
 
 func (a basicEdgeArray) Value() (driver.Value, error) {
-	return nil, errors.New("Value() on an array of edge is not supported")
+	if a == nil {
+		return nil, nil
+	}
+
+	return appendEntityArray(nil, len(a), func(i int) EntityLoader {
+		return a[i]
+	})
+}
+
+// TypedEdge can be used to scan the value from the database driver as an edge
+// whose properties are stored in P.
+//
+// Properties are stored by calling UnmarshalProperties, so P may be a struct
+// that uses "ag" tags. An array of TypedEdge can be scanned by Array.
+type TypedEdge[P any] struct {
+	EdgeHeader
+	Properties P
+}
+
+func (e TypedEdge[P]) String() string {
+	if e.Valid {
+		p, _ := MarshalProperties(e.Properties)
+		return fmt.Sprintf("%s[%s][%s,%s]%s", quoteLabel(e.Label), e.Id, e.Start, e.End, p)
+	} else {
+		return "NULL"
+	}
+}
+
+// SaveProperties implements PropertiesSaver interface. It calls
+// UnmarshalProperties to unmarshal b and store the result in Properties.
+func (e *TypedEdge[P]) SaveProperties(b []byte) error {
+	var p P
+	err := UnmarshalProperties(b, &p)
+	if err != nil {
+		return errors.New("invalid edge properties: " + err.Error())
+	}
+	e.Properties = p
+	return nil
+}
+
+// LoadProperties implements PropertiesLoader interface. It calls
+// MarshalProperties to marshal Properties.
+func (e TypedEdge[P]) LoadProperties() ([]byte, error) {
+	return MarshalProperties(e.Properties)
+}
+
+// Scan implements the database/sql Scanner interface. It calls ScanEntity.
+func (e *TypedEdge[P]) Scan(src interface{}) error {
+	return ScanEntity(src, e)
+}
+
+// Value implements the database/sql/driver Valuer interface. It calls
+// ValueEntity.
+func (e TypedEdge[P]) Value() (driver.Value, error) {
+	return ValueEntity(e)
 }
@@ -112,18 +112,126 @@ func TestBasicEdgeArrayScan(t *testing.T) {
 	}
 }
 
+// (BasicEdge).Value, ValueEntity, (EdgeHeader).LoadEntity,
+// (BasicEdge).LoadProperties
+func TestBasicEdgeValue(t *testing.T) {
+	var e BasicEdge
+	val, err := e.Value()
+	if err != nil {
+		t.Error(err)
+	} else if val != nil {
+		t.Errorf("got %s, want nil", val)
+	}
+
+	b := []byte(`e[4.1][3.1,3.2]{"name": "go"}`)
+	err = e.Scan(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, err = e.Value()
+	if err != nil {
+		t.Error(err)
+	} else if want := `e[4.1][3.1,3.2]{"name":"go"}`; string(val.([]byte)) != want {
+		t.Errorf("got %s, want %s", val, want)
+	}
+
+	e.Start = GraphId{}
+	_, err = e.Value()
+	if err == nil {
+		t.Errorf("error expected for NULL start ID")
+	}
+}
+
 func TestBasicEdgeArrayValue(t *testing.T) {
 	var es []BasicEdge
-	_, err := Array(&es).Value()
+	err := Array(&es).Scan([]byte(`[e[4.1][3.1,3.2]{"name": "go"},NULL]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `[e[4.1][3.1,3.2]{"name":"go"},NULL]`
+	for _, a := range []interface{}{&es, es} {
+		val, err := Array(a).Value()
+		if err != nil {
+			t.Error(err)
+		} else if string(val.([]byte)) != want {
+			t.Errorf("got %s, want %s", val, want)
+		}
+	}
+}
+
+type plainEdge struct {
+	EdgeHeader
+	Since int `json:"since"`
+}
+
+// ValueEntity - MarshalProperties without "ag" tags
+func TestPlainEdgeValue(t *testing.T) {
+	var e plainEdge
+	err := ScanEntity([]byte(`knows[4.1][3.1,3.2]{"since": 2018}`), &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := ValueEntity(&e)
+	if err != nil {
+		t.Error(err)
+	} else if want := `knows[4.1][3.1,3.2]{"since":2018}`; string(val.([]byte)) != want {
+		t.Errorf("got %s, want %s", val, want)
+	}
+}
+
+type typedEdgeProps struct {
+	Since int `ag:"since,required"`
+}
+
+func TestTypedEdgeScan(t *testing.T) {
+	var e TypedEdge[typedEdgeProps]
+	err := e.Scan([]byte(`e[4.1][3.1,3.2]{"since": 2009}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Valid {
+		t.Errorf("got NULL, want Valid %T", e)
+	} else if e.Properties.Since != 2009 {
+		t.Errorf("got %d, want 2009", e.Properties.Since)
+	}
+	if s, want := e.String(), `e[4.1][3.1,3.2]{"since":2009}`; s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+
+	err = e.Scan([]byte(`e[4.1][3.1,3.2]{}`))
 	if err == nil {
-		t.Error("error expected for Value() on basicEdgeArray")
+		t.Error("error expected for missing required property")
+	}
+}
+
+func TestTypedEdgeArray(t *testing.T) {
+	src := []byte(`[e[4.1][3.1,3.2]{"since": 1970},NULL]`)
+	var es []TypedEdge[typedEdgeProps]
+	err := Array(&es).Scan(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(es); n != 2 {
+		t.Fatalf("got len(es) == %d, want 2", n)
+	}
+	if es[0].Properties.Since != 1970 || es[1].Valid {
+		t.Errorf("got %v", es)
+	}
+
+	val, err := Array(es).Value()
+	if err != nil {
+		t.Error(err)
+	} else if want := `[e[4.1][3.1,3.2]{"since":1970},NULL]`; string(val.([]byte)) != want {
+		t.Errorf("got %s, want %s", val, want)
 	}
 }
 
 func TestServerEdge(t *testing.T) {
 	skipUnlessServerTest(t)
 
-	db := mustOpenAndSetGraph(t)
+	db := mustOpenGraph(t)
 	defer db.Close()
 
 	_, err := db.Exec(`CREATE (:ev)-[:ee]->(:ev)-[:ee]->(:ev)`)
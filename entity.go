@@ -17,7 +17,9 @@ limitations under the License.
 package ag
 
 import (
-	"encoding/json"
+	"bytes"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 )
 
@@ -55,8 +57,9 @@ type EntitySaver interface {
 // PropertiesSaver is an interface used by ScanEntity.
 type PropertiesSaver interface {
 	// By default, properties of an entity read by ScanEntity are stored in
-	// the entity itself by calling json.Unmarshal over it. To modify this
-	// default behavior, one may implement PropertiesSaver for the entity.
+	// the entity itself by calling UnmarshalProperties over it. To modify
+	// this default behavior, one may implement PropertiesSaver for the
+	// entity.
 	//
 	// The underlying array of b may be reused.
 	//
@@ -103,7 +106,114 @@ func saveEntityData(d *entityData, entity Entity) error {
 	if p, ok := entity.(PropertiesSaver); ok {
 		err = p.SaveProperties(d.properties)
 	} else {
-		err = json.Unmarshal(d.properties, entity)
+		err = UnmarshalProperties(d.properties, entity)
 	}
-	return err
+	if err != nil {
+		return err
+	}
+
+	if s, ok := entity.(propertiesSnapshotSaver); ok {
+		s.savePropertiesSnapshot(d.properties)
+	}
+	return nil
+}
+
+// EntityLoader is an interface used by ValueEntity.
+type EntityLoader interface {
+	// LoadEntity returns an entity to pass it to the database driver.
+	//
+	// valid is false if the entity is NULL.
+	//
+	// core is VertexCore or EdgeCore of the entity for vertex or edge
+	// respectively. It is ignored if valid is false.
+	LoadEntity() (valid bool, core interface{})
+}
+
+// PropertiesLoader is an interface used by ValueEntity.
+type PropertiesLoader interface {
+	// By default, properties of an entity written by ValueEntity are the
+	// result of calling MarshalProperties over the entity itself. To modify
+	// this default behavior, one may implement PropertiesLoader for the
+	// entity.
+	//
+	// The result must be a JSON object.
+	LoadProperties() ([]byte, error)
+}
+
+// ValueEntity returns the text representation of the given entity for vertex
+// or edge as a database/sql/driver Value. If the entity is NULL, it returns
+// nil.
+func ValueEntity(entity EntityLoader) (driver.Value, error) {
+	if valid, _ := entity.LoadEntity(); !valid {
+		return nil, nil
+	}
+	return appendEntity(nil, entity)
+}
+
+func appendEntity(b []byte, entity EntityLoader) ([]byte, error) {
+	valid, core := entity.LoadEntity()
+	if !valid {
+		return append(b, nullElementValue...), nil
+	}
+
+	props, err := loadProperties(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := core.(type) {
+	case VertexCore:
+		b, err = appendVertexCore(b, c)
+	case EdgeCore:
+		b, err = appendEdgeCore(b, c)
+	default:
+		err = fmt.Errorf("invalid entity core: %T", core)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, props...), nil
+}
+
+// loadProperties returns the properties of entity as ValueEntity does.
+func loadProperties(entity interface{}) ([]byte, error) {
+	var props []byte
+	var err error
+	if p, ok := entity.(PropertiesLoader); ok {
+		props, err = p.LoadProperties()
+	} else {
+		props, err = MarshalProperties(entity)
+	}
+	if err != nil {
+		return nil, errors.New("invalid properties: " + err.Error())
+	}
+	props = bytes.TrimSpace(props)
+	if bytes.Equal(props, []byte("null")) {
+		// nil map or pointer
+		props = []byte("{}")
+	}
+	if len(props) < 1 || props[0] != byte('{') {
+		return nil, fmt.Errorf("invalid properties: %s", props)
+	}
+	return props, nil
+}
+
+// separated by comma (see vertex and edge in pg_type.h)
+const entitySeparator = byte(054)
+
+func appendEntityArray(b []byte, n int, entity func(i int) EntityLoader) ([]byte, error) {
+	b = append(b, '[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b = append(b, entitySeparator)
+		}
+
+		var err error
+		b, err = appendEntity(b, entity(i))
+		if err != nil {
+			return nil, errors.New("invalid element: " + err.Error())
+		}
+	}
+	return append(b, ']'), nil
 }
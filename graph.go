@@ -0,0 +1,128 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+)
+
+// Graph is a handle to a graph of AgensGraph over *sql.DB.
+//
+// graph_path is a session variable, so setting it through *sql.DB affects
+// only one of the pooled connections. Graph opens its own *sql.DB with
+// NewConnector and WithGraphPath instead, so every connection of the pool has
+// graph_path set when it is created and no query needs a round trip to set
+// it. Graph is safe for concurrent use.
+type Graph struct {
+	db   *sql.DB
+	name string
+}
+
+// NewGraph returns Graph of the graph name. Its connections are created by
+// base and initialized by opts and then by WithGraphPath(name). The caller
+// must call Close of Graph when done with it.
+//
+//	base, _ := pq.NewConnector("")
+//	g := ag.NewGraph(base, "g", ag.WithStatementTimeout(time.Minute))
+//	defer g.Close()
+func NewGraph(base driver.Connector, name string, opts ...ConnectorOption) *Graph {
+	opts = append(opts[:len(opts):len(opts)], WithGraphPath(name))
+	return &Graph{sql.OpenDB(NewConnector(base, opts...)), name}
+}
+
+// Name returns the name of g.
+func (g *Graph) Name() string {
+	return g.name
+}
+
+// DB returns the underlying *sql.DB of g. graph_path of its connections is
+// set to the graph of g.
+func (g *Graph) DB() *sql.DB {
+	return g.db
+}
+
+// Close closes the underlying *sql.DB of g.
+func (g *Graph) Close() error {
+	return g.db.Close()
+}
+
+// Create creates the graph of g if it does not exist.
+func (g *Graph) Create(ctx context.Context) error {
+	_, err := g.db.ExecContext(ctx, `CREATE GRAPH IF NOT EXISTS `+quoteIdentifier(g.name))
+	return err
+}
+
+// Drop drops the graph of g. If cascade is true, the objects that depend on
+// the graph are dropped as well.
+func (g *Graph) Drop(ctx context.Context, cascade bool) error {
+	q := `DROP GRAPH ` + quoteIdentifier(g.name)
+	if cascade {
+		q += ` CASCADE`
+	}
+	_, err := g.db.ExecContext(ctx, q)
+	return err
+}
+
+// ExecContext executes a query that doesn't return rows in the graph of g.
+func (g *Graph) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return g.db.ExecContext(ctx, query, args...)
+}
+
+// Exec executes a query that doesn't return rows in the graph of g.
+func (g *Graph) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return g.ExecContext(context.Background(), query, args...)
+}
+
+// QueryContext executes a query that returns rows in the graph of g.
+func (g *Graph) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return g.db.QueryContext(ctx, query, args...)
+}
+
+// Query executes a query that returns rows in the graph of g.
+func (g *Graph) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return g.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRowContext executes a query that is expected to return at most one row
+// in the graph of g.
+func (g *Graph) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return g.db.QueryRowContext(ctx, query, args...)
+}
+
+// QueryRow executes a query that is expected to return at most one row in the
+// graph of g.
+func (g *Graph) QueryRow(query string, args ...interface{}) *sql.Row {
+	return g.QueryRowContext(context.Background(), query, args...)
+}
+
+// BeginTx starts a transaction in the graph of g.
+func (g *Graph) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return g.db.BeginTx(ctx, opts)
+}
+
+// Begin starts a transaction in the graph of g.
+func (g *Graph) Begin() (*sql.Tx, error) {
+	return g.BeginTx(context.Background(), nil)
+}
+
+// quoteIdentifier double-quotes name for SQL.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// fakeDriver keeps graph_path of each connection and records the statements.
+// A query returns graph_path of the connection that runs it unless query is
+// set.
+type fakeDriver struct {
+	mu     sync.Mutex
+	stmts  []string
+	conns  int
+	closed int
+
+	query func(query string, args []driver.NamedValue) (driver.Rows, error)
+	exec  func(query string, args []driver.NamedValue) error
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.conns++
+	return &fakeConn{d: d}, nil
+}
+
+func (d *fakeDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *fakeDriver) Driver() driver.Driver {
+	return d
+}
+
+func (d *fakeDriver) record(stmt string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stmts = append(d.stmts, stmt)
+}
+
+func (d *fakeDriver) statements() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.stmts...)
+}
+
+type fakeConn struct {
+	d         *fakeDriver
+	graphPath string
+	inTx      bool
+	txPath    string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c, query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.closed++
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.d.record("BEGIN")
+	c.inTx, c.txPath = true, c.graphPath
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.inTx, c.graphPath = false, c.txPath
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	return c.Commit()
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.record(query)
+
+	const set = "SET graph_path = "
+	switch {
+	case strings.HasPrefix(query, set):
+		c.graphPath = strings.TrimPrefix(query, set)
+		if c.inTx {
+			c.txPath = c.graphPath
+		}
+	case strings.HasPrefix(query, "ERROR"):
+		return nil, errors.New(query)
+	}
+	if c.d.exec != nil {
+		err := c.d.exec(query, args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.d.record(query)
+	if c.d.query != nil {
+		return c.d.query(query, args)
+	}
+	return &fakeRows{[]string{"graph_path"}, [][]driver.Value{{c.graphPath}}}, nil
+}
+
+// fakeStmt runs the query on its connection on every execution.
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("Exec not supported")
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("Query not supported")
+}
+
+func (s *fakeStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.c.ExecContext(ctx, s.query, args)
+}
+
+func (s *fakeStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.c.QueryContext(ctx, s.query, args)
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) < 1 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func newFakeDB() (*sql.DB, *fakeDriver) {
+	d := &fakeDriver{}
+	return sql.OpenDB(d), d
+}
+
+func TestGraphQuery(t *testing.T) {
+	da, db := &fakeDriver{}, &fakeDriver{}
+	a := NewGraph(da, "a")
+	defer a.Close()
+	b := NewGraph(db, "B", WithStatementTimeout(0))
+	defer b.Close()
+
+	for i := 0; i < 3; i++ {
+		for _, g := range []*Graph{a, b} {
+			want := quoteIdentifier(g.Name())
+
+			// QueryRow runs on another connection while rows is open
+			rows, err := g.Query(`SHOW graph_path`)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var path string
+			err = g.QueryRow(`SHOW graph_path`).Scan(&path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path != want {
+				t.Errorf("got %s, want %s", path, want)
+			}
+
+			for rows.Next() {
+				err = rows.Scan(&path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if path != want {
+					t.Errorf("got %s, want %s", path, want)
+				}
+			}
+			rows.Close()
+		}
+	}
+
+	// graph_path is set once on each of the two connections, after the
+	// other options
+	for d, want := range map[*fakeDriver][]string{
+		da: {`SET graph_path = "a"`, `SET graph_path = "a"`},
+		db: {`SET statement_timeout = 0`, `SET graph_path = "B"`, `SET statement_timeout = 0`, `SET graph_path = "B"`},
+	} {
+		var sets []string
+		for _, s := range d.statements() {
+			if strings.HasPrefix(s, "SET ") {
+				sets = append(sets, s)
+			}
+		}
+		if strings.Join(sets, "\n") != strings.Join(want, "\n") {
+			t.Errorf("got %q, want %q", sets, want)
+		}
+	}
+
+	_, err := a.Exec(`ERROR`)
+	if err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestGraphBeginTx(t *testing.T) {
+	d := &fakeDriver{}
+	g := NewGraph(d, "a")
+	defer g.Close()
+
+	tx, err := g.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	var path string
+	err = tx.QueryRow(`SHOW graph_path`).Scan(&path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != `"a"` {
+		t.Errorf("got %s, want %s", path, `"a"`)
+	}
+}
+
+func TestGraphCreateDrop(t *testing.T) {
+	d := &fakeDriver{}
+	g := NewGraph(d, `a"b`)
+	defer g.Close()
+	err := g.Create(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.Drop(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.Drop(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`SET graph_path = "a""b"`,
+		`CREATE GRAPH IF NOT EXISTS "a""b"`,
+		`DROP GRAPH "a""b"`,
+		`DROP GRAPH "a""b" CASCADE`,
+	}
+	stmts := d.statements()
+	if strings.Join(stmts, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", stmts, want)
+	}
+}
+
+func TestServerGraph(t *testing.T) {
+	skipUnlessServerTest(t)
+
+	base, err := pq.NewConnector("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	g := NewGraph(base, agTestGraphName+"_session")
+	defer g.Close()
+	err = g.Create(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Drop(ctx, true)
+
+	_, err = g.Exec(`CREATE (:v)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cnt int64
+	err = g.QueryRow(`MATCH (n:v) RETURN count(n)`).Scan(&cnt)
+	if err != nil {
+		t.Error(err)
+	} else if cnt != 1 {
+		t.Errorf("got %d, want %d", cnt, 1)
+	}
+
+	tx, err := g.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`MATCH (n:v) RETURN count(n)`).Scan(&cnt)
+	if err != nil {
+		t.Error(err)
+	} else if cnt != 1 {
+		t.Errorf("got %d, want %d", cnt, 1)
+	}
+}
@@ -19,9 +19,10 @@ package ag
 import (
 	"bytes"
 	"database/sql/driver"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"regexp"
 	"strconv"
 )
 
@@ -30,57 +31,94 @@ type GraphId struct {
 	// Valid is true if GraphId is not NULL
 	Valid bool
 
-	b []byte
+	b  []byte // text representation; nil if it has not been formatted yet
+	id uint64 // label ID and local ID in the layout of graphid
 }
 
 var nullGraphId = GraphId{}
 
-var graphIdRegexp = regexp.MustCompile(`^(\d+)\.(\d+)$`)
-
 // NewGraphId returns GraphId of str if str is between "1.1" and
 // "65535.281474976710656". If str is "NULL", it returns GraphId whose Valid is
 // false. Otherwise, it returns an error.
+//
+// The text representation of the result is canonical; "03.014" becomes
+// "3.14".
 func NewGraphId(str string) (GraphId, error) {
 	if str == "NULL" {
 		return nullGraphId, nil
 	}
 
-	err := validateGraphId(str)
+	id, err := parseGraphId(str)
+	if err != nil {
+		return GraphId{}, err
+	}
+
+	gid := GraphId{Valid: true, id: id}
+	gid.b = gid.appendText(nil)
+	return gid, nil
+}
+
+// NewGraphIdFromParts returns GraphId whose label ID is labelId and local ID
+// is localId. It returns an error if either of them is 0 or localId does not
+// fit in 48 bits.
+func NewGraphIdFromParts(labelId uint16, localId uint64) (GraphId, error) {
+	err := checkGraphIdParts(labelId, localId)
 	if err != nil {
 		return GraphId{}, err
 	}
 
-	return GraphId{true, []byte(str)}, nil
+	b := strconv.AppendUint(nil, uint64(labelId), 10)
+	b = append(b, '.')
+	b = strconv.AppendUint(b, localId, 10)
+
+	return GraphId{true, b, makeGraphIdUint64(labelId, localId)}, nil
+}
+
+// NewGraphIdFromUint64 returns GraphId of u which is in the same layout as
+// graphid of AgensGraph; the upper 16 bits are the label ID and the lower 48
+// bits are the local ID.
+func NewGraphIdFromUint64(u uint64) (GraphId, error) {
+	return NewGraphIdFromParts(uint16(u>>localBit), u&localIdMax)
+}
+
+func checkGraphIdParts(labelId uint16, localId uint64) error {
+	if labelId == 0 {
+		return fmt.Errorf("invalid label ID: %d", labelId)
+	}
+	if localId == 0 || localId > localIdMax {
+		return fmt.Errorf("invalid local ID: %d", localId)
+	}
+	return nil
 }
 
 const (
 	labelBit = 16
 	localBit = 48
+
+	localIdMax = 1<<localBit - 1
 )
 
-func validateGraphId(str string) error {
-	m := graphIdRegexp.FindStringSubmatch(str)
-	if m == nil {
-		return fmt.Errorf("bad graphid representation: %q", str)
-	}
+func makeGraphIdUint64(labelId uint16, localId uint64) uint64 {
+	return uint64(labelId)<<localBit | localId
+}
 
-	i, err := strconv.ParseUint(m[1], 10, labelBit)
-	if err != nil {
-		return errors.New("invalid label ID: " + err.Error())
-	}
-	if i == 0 {
-		return fmt.Errorf("invalid label ID: %d", i)
-	}
+// LabelId returns the label ID of gid. It returns 0 if gid is NULL.
+func (gid GraphId) LabelId() uint16 {
+	return uint16(gid.Uint64() >> localBit)
+}
 
-	i, err = strconv.ParseUint(m[2], 10, localBit)
-	if err != nil {
-		return errors.New("invalid local ID: " + err.Error())
-	}
-	if i == 0 {
-		return fmt.Errorf("invalid local ID: %d", i)
-	}
+// LocalId returns the local ID of gid. It returns 0 if gid is NULL.
+func (gid GraphId) LocalId() uint64 {
+	return gid.Uint64() & localIdMax
+}
 
-	return nil
+// Uint64 returns gid in the same layout as graphid of AgensGraph. It returns 0
+// if gid is NULL.
+func (gid GraphId) Uint64() uint64 {
+	if !gid.Valid {
+		return 0
+	}
+	return gid.id
 }
 
 // Equal reports whether gid and x are the same GraphId.
@@ -88,21 +126,84 @@ func (gid GraphId) Equal(x GraphId) bool {
 	if !gid.Valid || !x.Valid {
 		return false
 	}
-	return bytes.Equal(gid.b, x.b)
+	return gid.id == x.id
+}
+
+// Compare returns an integer comparing gid and x. The result will be 0 if
+// gid == x, -1 if gid < x, and +1 if gid > x. GraphIds are ordered by the label
+// ID and then the local ID, and NULL is less than any valid GraphId. Two NULLs
+// are considered equal.
+//
+// GraphId.Compare can be used as the comparison function of slices.SortFunc
+// and slices.BinarySearchFunc.
+func (gid GraphId) Compare(x GraphId) int {
+	a, b := gid.Uint64(), x.Uint64()
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// GraphIdKey is a comparable form of GraphId. It can be used as a map key
+// while GraphId itself cannot. The zero value of GraphIdKey represents NULL.
+type GraphIdKey uint64
+
+// Key returns GraphIdKey of gid.
+func (gid GraphId) Key() GraphIdKey {
+	return GraphIdKey(gid.Uint64())
+}
+
+// GraphId returns GraphId of k. It returns NULL if k is the zero value or
+// invalid.
+func (k GraphIdKey) GraphId() GraphId {
+	if k == 0 {
+		return nullGraphId
+	}
+	gid, err := NewGraphIdFromUint64(uint64(k))
+	if err != nil {
+		return nullGraphId
+	}
+	return gid
+}
+
+func (k GraphIdKey) String() string {
+	return k.GraphId().String()
 }
 
 func (gid GraphId) String() string {
 	if gid.Valid {
-		return string(gid.b)
+		return string(gid.text())
 	} else {
 		return "NULL"
 	}
 }
 
+// text returns the text representation of valid gid. GraphId read in binary
+// format is formatted on demand.
+func (gid GraphId) text() []byte {
+	if gid.b != nil {
+		return gid.b
+	}
+	return gid.appendText(make([]byte, 0, 8))
+}
+
+func (gid GraphId) appendText(b []byte) []byte {
+	if gid.b != nil {
+		return append(b, gid.b...)
+	}
+	b = strconv.AppendUint(b, uint64(gid.LabelId()), 10)
+	b = append(b, '.')
+	return strconv.AppendUint(b, gid.LocalId(), 10)
+}
+
 // Scan implements the database/sql Scanner interface.
 func (gid *GraphId) Scan(src interface{}) error {
 	if src == nil {
-		gid.Valid, gid.b = false, nil
+		gid.Valid, gid.b, gid.id = false, nil, 0
 		return nil
 	}
 
@@ -114,24 +215,125 @@ func (gid *GraphId) Scan(src interface{}) error {
 		return fmt.Errorf("invalid source for graphid: %v", b)
 	}
 
-	err := validateGraphId(string(b))
+	id, err := parseGraphId(b)
 	if err != nil {
 		return err
 	}
 
-	gid.Valid, gid.b = true, append([]byte(nil), b...)
+	gid.Valid, gid.b, gid.id = true, append([]byte(nil), b...), id
+	return nil
+}
+
+// setBytes is the same as Scan except that gid references b without copying
+// it.
+func (gid *GraphId) setBytes(b []byte) error {
+	id, err := parseGraphId(b)
+	if err != nil {
+		return err
+	}
+
+	gid.Valid, gid.b, gid.id = true, b, id
 	return nil
 }
 
 // Value implements the database/sql/driver Valuer interface.
 func (gid GraphId) Value() (driver.Value, error) {
 	if gid.Valid {
-		return gid.b, nil
+		return gid.text(), nil
 	} else {
 		return nil, nil
 	}
 }
 
+// MarshalText implements the encoding TextMarshaler interface. NULL is
+// encoded as "NULL".
+func (gid GraphId) MarshalText() ([]byte, error) {
+	if gid.Valid {
+		return gid.appendText(nil), nil
+	} else {
+		return append([]byte(nil), nullElementValue...), nil
+	}
+}
+
+// UnmarshalText implements the encoding TextUnmarshaler interface. It accepts
+// the same representations as NewGraphId.
+func (gid *GraphId) UnmarshalText(b []byte) error {
+	x, err := NewGraphId(string(b))
+	if err != nil {
+		return err
+	}
+	*gid = x
+	return nil
+}
+
+// MarshalJSON implements the encoding/json Marshaler interface. A valid GraphId
+// is encoded as a JSON string such as "3.14" and NULL is encoded as null.
+func (gid GraphId) MarshalJSON() ([]byte, error) {
+	if !gid.Valid {
+		return []byte("null"), nil
+	}
+
+	b := make([]byte, 0, len(gid.b)+2)
+	b = append(b, '"')
+	b = gid.appendText(b)
+	b = append(b, '"')
+	return b, nil
+}
+
+// UnmarshalJSON implements the encoding/json Unmarshaler interface. It accepts
+// null or a JSON string that NewGraphId accepts.
+func (gid *GraphId) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*gid = nullGraphId
+		return nil
+	}
+
+	var str string
+	err := json.Unmarshal(b, &str)
+	if err != nil {
+		return errors.New("invalid JSON for graphid: " + err.Error())
+	}
+
+	return gid.UnmarshalText([]byte(str))
+}
+
+// graphid is sent and received as an 8-byte big-endian integer in binary
+// format (see graphid_send and graphid_recv of AgensGraph).
+const graphIdBinaryLen = 8
+
+// AppendBinary appends the binary format of graphid of gid to b. It returns an
+// error if gid is NULL because NULL has no binary format.
+func (gid GraphId) AppendBinary(b []byte) ([]byte, error) {
+	if !gid.Valid {
+		return nil, errors.New("invalid graphid: NULL")
+	}
+	return binary.BigEndian.AppendUint64(b, gid.id), nil
+}
+
+// MarshalBinary implements the encoding BinaryMarshaler interface. It is the
+// same as AppendBinary(nil).
+func (gid GraphId) MarshalBinary() ([]byte, error) {
+	return gid.AppendBinary(make([]byte, 0, graphIdBinaryLen))
+}
+
+// UnmarshalBinary implements the encoding BinaryUnmarshaler interface. b must
+// be graphid in binary format. The text representation of gid is not built
+// until it is needed.
+func (gid *GraphId) UnmarshalBinary(b []byte) error {
+	if len(b) != graphIdBinaryLen {
+		return fmt.Errorf("invalid binary graphid length: %d", len(b))
+	}
+
+	id := binary.BigEndian.Uint64(b)
+	err := checkGraphIdParts(uint16(id>>localBit), id&localIdMax)
+	if err != nil {
+		return err
+	}
+
+	gid.Valid, gid.b, gid.id = true, nil, id
+	return nil
+}
+
 type graphIdArray []GraphId
 
 // separated by comma (see graphid in pg_type.h)
@@ -208,3 +410,102 @@ func (a graphIdArray) Value() (driver.Value, error) {
 
 	return []byte("{}"), nil
 }
+
+// The binary format of _graphid is that of one-dimensional arrays of
+// PostgreSQL (see array_send and array_recv).
+//
+//	int32 ndim, int32 flags, uint32 element type OID
+//	int32 length, int32 lower bound     // for each dimension
+//	int32 size, size bytes              // for each element; size is -1 for NULL
+
+// AppendGraphIdArrayBinary appends gids in the binary format of _graphid to b.
+// elemOid is the OID of graphid, which the server checks on receive.
+func AppendGraphIdArrayBinary(b []byte, gids []GraphId, elemOid uint32) []byte {
+	if len(gids) < 1 {
+		b = binary.BigEndian.AppendUint32(b, 0) // ndim
+		b = binary.BigEndian.AppendUint32(b, 0) // flags
+		return binary.BigEndian.AppendUint32(b, elemOid)
+	}
+
+	var hasNull uint32
+	for i := range gids {
+		if !gids[i].Valid {
+			hasNull = 1
+			break
+		}
+	}
+
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = binary.BigEndian.AppendUint32(b, hasNull)
+	b = binary.BigEndian.AppendUint32(b, elemOid)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(gids)))
+	b = binary.BigEndian.AppendUint32(b, 1) // lower bound
+	for i := range gids {
+		if !gids[i].Valid {
+			b = binary.BigEndian.AppendUint32(b, 0xffffffff) // -1
+			continue
+		}
+		b = binary.BigEndian.AppendUint32(b, graphIdBinaryLen)
+		b = binary.BigEndian.AppendUint64(b, gids[i].id)
+	}
+	return b
+}
+
+// ParseGraphIdArrayBinary returns GraphIds of src which is _graphid in binary
+// format. It returns nil if src is nil, which is NULL, and an empty slice for
+// an empty array. Multi-dimensional arrays are not supported.
+func ParseGraphIdArrayBinary(src []byte) ([]GraphId, error) {
+	if src == nil {
+		return nil, nil
+	}
+
+	if len(src) < 12 {
+		return nil, errors.New("bad _graphid binary representation: short header")
+	}
+	ndim := int32(binary.BigEndian.Uint32(src))
+	src = src[12:] // skip flags and element type OID
+
+	switch {
+	case ndim == 0:
+		if len(src) > 0 {
+			return nil, fmt.Errorf("bad _graphid binary representation: %d trailing bytes", len(src))
+		}
+		return []GraphId{}, nil
+	case ndim > 1:
+		return nil, fmt.Errorf("multi-dimensional _graphid is not supported: %d", ndim)
+	case ndim < 0 || len(src) < 8:
+		return nil, errors.New("bad _graphid binary representation: invalid dimension")
+	}
+
+	n := int32(binary.BigEndian.Uint32(src))
+	src = src[8:] // skip lower bound
+	if n < 0 || len(src) < int(n)*4 {
+		return nil, fmt.Errorf("bad _graphid binary representation: invalid length %d", n)
+	}
+
+	gids := make([]GraphId, n)
+	for i := range gids {
+		if len(src) < 4 {
+			return nil, errors.New("bad _graphid binary representation: unexpected end")
+		}
+		size := int32(binary.BigEndian.Uint32(src))
+		src = src[4:]
+		if size == -1 {
+			continue
+		}
+		if size != graphIdBinaryLen || len(src) < graphIdBinaryLen {
+			return nil, fmt.Errorf("bad _graphid binary representation: invalid element size %d", size)
+		}
+
+		err := gids[i].UnmarshalBinary(src[:graphIdBinaryLen])
+		if err != nil {
+			return nil, errors.New("bad _graphid binary representation: " + err.Error())
+		}
+		src = src[graphIdBinaryLen:]
+	}
+	if len(src) > 0 {
+		return nil, fmt.Errorf("bad _graphid binary representation: %d trailing bytes", len(src))
+	}
+
+	return gids, nil
+}
@@ -18,6 +18,9 @@ package ag
 
 import (
 	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
 	"testing"
 )
 
@@ -64,6 +67,247 @@ func TestGraphIdEqual(t *testing.T) {
 	}
 }
 
+func TestNewGraphIdFromParts(t *testing.T) {
+	tests := []struct {
+		labelId uint16
+		localId uint64
+		str     string
+	}{
+		{1, 1, "1.1"},
+		{3, 14, "3.14"},
+		{65535, 281474976710655, "65535.281474976710655"},
+	}
+	for _, c := range tests {
+		gid, err := NewGraphIdFromParts(c.labelId, c.localId)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if s := gid.String(); s != c.str {
+			t.Errorf("got %q, want %q", s, c.str)
+		}
+		if !gid.Equal(mustNewGraphId(c.str)) {
+			t.Errorf("got %q, want equal to %q", gid, c.str)
+		}
+	}
+}
+
+func TestNewGraphIdFromPartsError(t *testing.T) {
+	tests := []struct {
+		labelId uint16
+		localId uint64
+	}{
+		{0, 1},
+		{1, 0},
+		{1, 281474976710656},
+	}
+	for _, c := range tests {
+		_, err := NewGraphIdFromParts(c.labelId, c.localId)
+		if err == nil {
+			t.Errorf("error expected for (%d, %d)", c.labelId, c.localId)
+		}
+	}
+}
+
+func TestGraphIdParts(t *testing.T) {
+	tests := []struct {
+		gid     GraphId
+		labelId uint16
+		localId uint64
+		u       uint64
+	}{
+		{mustNewGraphId("NULL"), 0, 0, 0},
+		{mustNewGraphId("1.1"), 1, 1, 0x0001000000000001},
+		{mustNewGraphId("3.14"), 3, 14, 0x000300000000000e},
+		{mustNewGraphId("65535.281474976710655"), 65535, 281474976710655, 0xffffffffffffffff},
+	}
+	for _, c := range tests {
+		if labelId := c.gid.LabelId(); labelId != c.labelId {
+			t.Errorf("got %q.LabelId() == %d, want %d", c.gid, labelId, c.labelId)
+		}
+		if localId := c.gid.LocalId(); localId != c.localId {
+			t.Errorf("got %q.LocalId() == %d, want %d", c.gid, localId, c.localId)
+		}
+		if u := c.gid.Uint64(); u != c.u {
+			t.Errorf("got %q.Uint64() == %#x, want %#x", c.gid, u, c.u)
+		}
+		if !c.gid.Valid {
+			continue
+		}
+		gid, err := NewGraphIdFromUint64(c.u)
+		if err != nil {
+			t.Error(err)
+		} else if !gid.Equal(c.gid) {
+			t.Errorf("got %q, want %q", gid, c.gid)
+		}
+	}
+}
+
+func TestGraphIdScanParts(t *testing.T) {
+	var gid GraphId
+	err := gid.Scan([]byte("3.14"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labelId, localId := gid.LabelId(), gid.LocalId(); labelId != 3 || localId != 14 {
+		t.Errorf("got (%d, %d), want (3, 14)", labelId, localId)
+	}
+}
+
+func TestGraphIdCompare(t *testing.T) {
+	tests := []struct {
+		x GraphId
+		y GraphId
+		c int
+	}{
+		{mustNewGraphId("NULL"), mustNewGraphId("NULL"), 0},
+		{mustNewGraphId("NULL"), mustNewGraphId("1.1"), -1},
+		{mustNewGraphId("1.1"), mustNewGraphId("NULL"), 1},
+		{mustNewGraphId("1.1"), mustNewGraphId("1.1"), 0},
+		{mustNewGraphId("1.2"), mustNewGraphId("1.10"), -1},
+		{mustNewGraphId("2.1"), mustNewGraphId("1.281474976710655"), 1},
+	}
+	for _, c := range tests {
+		if r := c.x.Compare(c.y); r != c.c {
+			t.Errorf("got %q.Compare(%q) == %d, want %d", c.x, c.y, r, c.c)
+		}
+	}
+}
+
+func TestGraphIdSort(t *testing.T) {
+	gids := []GraphId{
+		mustNewGraphId("3.1"),
+		mustNewGraphId("1.10"),
+		mustNewGraphId("NULL"),
+		mustNewGraphId("1.2"),
+	}
+	slices.SortFunc(gids, GraphId.Compare)
+
+	want := []string{"NULL", "1.2", "1.10", "3.1"}
+	for i, gid := range gids {
+		if s := gid.String(); s != want[i] {
+			t.Errorf("got %q at %d, want %q", s, i, want[i])
+		}
+	}
+
+	i, found := slices.BinarySearchFunc(gids, mustNewGraphId("1.10"), GraphId.Compare)
+	if !found || i != 2 {
+		t.Errorf("got (%d, %t), want (2, true)", i, found)
+	}
+}
+
+func TestGraphIdKey(t *testing.T) {
+	m := map[GraphIdKey]int{}
+	m[mustNewGraphId("3.1").Key()] = 1
+	m[mustNewGraphId("3.01").Key()]++
+
+	if n := m[mustNewGraphId("3.1").Key()]; n != 2 {
+		t.Errorf("got %d, want 2", n)
+	}
+
+	for _, s := range []string{"NULL", "1.1", "65535.281474976710655"} {
+		gid := mustNewGraphId(s)
+		k := gid.Key()
+		if r := k.GraphId(); r.Compare(gid) != 0 || r.Valid != gid.Valid {
+			t.Errorf("got %q, want %q", r, gid)
+		}
+		if ks := k.String(); ks != s {
+			t.Errorf("got %q, want %q", ks, s)
+		}
+	}
+}
+
+func TestNewGraphIdCanonical(t *testing.T) {
+	for str, want := range map[string]string{"03.014": "3.14", "1.1": "1.1"} {
+		gid := mustNewGraphId(str)
+		if s := gid.String(); s != want {
+			t.Errorf("got %q, want %q", s, want)
+		}
+		if v, _ := gid.Value(); string(v.([]byte)) != want {
+			t.Errorf("got %q, want %q", v, want)
+		}
+	}
+}
+
+func TestGraphIdText(t *testing.T) {
+	for _, str := range []string{"NULL", "3.14"} {
+		gid := mustNewGraphId(str)
+		b, err := gid.MarshalText()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(b) != str {
+			t.Errorf("got %q, want %q", b, str)
+		}
+
+		var x GraphId
+		err = x.UnmarshalText(b)
+		if err != nil {
+			t.Error(err)
+		} else if x.Valid != gid.Valid || x.Compare(gid) != 0 {
+			t.Errorf("got %q, want %q", x, gid)
+		}
+	}
+
+	var gid GraphId
+	if err := gid.UnmarshalText([]byte("0.1")); err == nil {
+		t.Errorf("error expected for %q", "0.1")
+	}
+}
+
+func TestGraphIdJSON(t *testing.T) {
+	type doc struct {
+		Id   GraphId
+		Ids  []GraphId
+		Null GraphId
+	}
+
+	in := doc{
+		Id:  mustNewGraphId("3.14"),
+		Ids: []GraphId{mustNewGraphId("1.1"), mustNewGraphId("NULL")},
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"Id":"3.14","Ids":["1.1",null],"Null":null}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	var out doc
+	err = json.Unmarshal(b, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !out.Id.Equal(in.Id) {
+		t.Errorf("got %q, want %q", out.Id, in.Id)
+	}
+	if len(out.Ids) != 2 || !out.Ids[0].Equal(in.Ids[0]) || out.Ids[1].Valid {
+		t.Errorf("got %v, want %v", out.Ids, in.Ids)
+	}
+	if out.Null.Valid {
+		t.Errorf("got %q, want NULL", out.Null)
+	}
+}
+
+func TestGraphIdJSONError(t *testing.T) {
+	tests := []string{
+		`3.14`,
+		`"0.1"`,
+		`"1.0"`,
+		`{}`,
+	}
+	for _, s := range tests {
+		var gid GraphId
+		err := json.Unmarshal([]byte(s), &gid)
+		if err == nil {
+			t.Errorf("error expected for %s", s)
+		}
+	}
+}
+
 func TestGraphIdScanNil(t *testing.T) {
 	var gid GraphId
 	err := gid.Scan(nil)
@@ -187,10 +431,186 @@ func TestGraphIdArrayValue(t *testing.T) {
 	}
 }
 
+// fixtures in the wire format of graphid_send and array_send
+var graphIdBinaryTests = []struct {
+	str string
+	hex string
+}{
+	{"1.1", "0001000000000001"},
+	{"3.14", "000300000000000e"},
+	{"7.4294967296", "0007000100000000"},
+	{"65535.281474976710655", "ffffffffffffffff"},
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestGraphIdBinary(t *testing.T) {
+	for _, c := range graphIdBinaryTests {
+		b, err := mustNewGraphId(c.str).MarshalBinary()
+		if err != nil {
+			t.Errorf("%s: %s", c.str, err)
+		} else if s := hex.EncodeToString(b); s != c.hex {
+			t.Errorf("got %s, want %s", s, c.hex)
+		}
+
+		var gid GraphId
+		err = gid.UnmarshalBinary(mustDecodeHex(c.hex))
+		if err != nil {
+			t.Errorf("%s: %s", c.hex, err)
+			continue
+		}
+		if !gid.Equal(mustNewGraphId(c.str)) {
+			t.Errorf("got %s, want %s", gid, c.str)
+		}
+
+		// the text representation is built on demand
+		if s := gid.String(); s != c.str {
+			t.Errorf("got %s, want %s", s, c.str)
+		}
+		if v, _ := gid.Value(); string(v.([]byte)) != c.str {
+			t.Errorf("got %s, want %s", v, c.str)
+		}
+		if j, _ := gid.MarshalJSON(); string(j) != `"`+c.str+`"` {
+			t.Errorf("got %s, want %q", j, c.str)
+		}
+	}
+}
+
+func TestGraphIdBinaryError(t *testing.T) {
+	_, err := mustNewGraphId("NULL").MarshalBinary()
+	if err == nil {
+		t.Error("error expected for NULL")
+	}
+
+	tests := []string{
+		"",
+		"00010000000001",
+		"000100000000000001",
+		"0000000000000001",
+		"0001000000000000",
+	}
+	for _, h := range tests {
+		var gid GraphId
+		err := gid.UnmarshalBinary(mustDecodeHex(h))
+		if err == nil {
+			t.Errorf("error expected for %s", h)
+		}
+	}
+}
+
+// '{3.1,NULL,65535.281474976710655}'::_graphid whose element type OID is 7002
+const graphIdArrayBinaryHex = "00000001" + "00000001" + "00001b5a" +
+	"00000003" + "00000001" +
+	"00000008" + "0003000000000001" +
+	"ffffffff" +
+	"00000008" + "ffffffffffffffff"
+
+// '{}'::_graphid
+const graphIdArrayBinaryEmptyHex = "00000000" + "00000000" + "00001b5a"
+
+func TestGraphIdArrayBinary(t *testing.T) {
+	gids := []GraphId{mustNewGraphId("3.1"), mustNewGraphId("NULL"), mustNewGraphId("65535.281474976710655")}
+
+	b := AppendGraphIdArrayBinary(nil, gids, 7002)
+	if s := hex.EncodeToString(b); s != graphIdArrayBinaryHex {
+		t.Errorf("got %s, want %s", s, graphIdArrayBinaryHex)
+	}
+
+	out, err := ParseGraphIdArrayBinary(mustDecodeHex(graphIdArrayBinaryHex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(gids) {
+		t.Fatalf("got %v, want %v", out, gids)
+	}
+	for i := range gids {
+		if gids[i].Compare(out[i]) != 0 || gids[i].Valid != out[i].Valid {
+			t.Errorf("got %s, want %s", out[i], gids[i])
+		}
+	}
+
+	b = AppendGraphIdArrayBinary(nil, []GraphId{}, 7002)
+	if s := hex.EncodeToString(b); s != graphIdArrayBinaryEmptyHex {
+		t.Errorf("got %s, want %s", s, graphIdArrayBinaryEmptyHex)
+	}
+	out, err = ParseGraphIdArrayBinary(mustDecodeHex(graphIdArrayBinaryEmptyHex))
+	if err != nil {
+		t.Error(err)
+	} else if out == nil || len(out) != 0 {
+		t.Errorf("got %v, want []", out)
+	}
+
+	out, err = ParseGraphIdArrayBinary(nil)
+	if err != nil {
+		t.Error(err)
+	} else if out != nil {
+		t.Errorf("got %v, want nil", out)
+	}
+}
+
+func TestGraphIdArrayBinaryError(t *testing.T) {
+	tests := []string{
+		"",
+		"00000001000000000000",
+		"00000000" + "00000000" + "00001b5a" + "00",
+		"00000002" + "00000000" + "00001b5a" + "00000001" + "00000001" + "00000001" + "00000001",
+		"00000001" + "00000000" + "00001b5a" + "00000001",
+		"00000001" + "00000000" + "00001b5a" + "00000002" + "00000001" + "00000008" + "0003000000000001",
+		"00000001" + "00000000" + "00001b5a" + "00000001" + "00000001" + "00000004" + "00030000",
+		"00000001" + "00000000" + "00001b5a" + "00000001" + "00000001" + "00000008" + "0000000000000001",
+		"00000001" + "00000000" + "00001b5a" + "00000001" + "00000001" + "00000008" + "0003000000000001" + "00",
+	}
+	for _, h := range tests {
+		_, err := ParseGraphIdArrayBinary(mustDecodeHex(h))
+		if err == nil {
+			t.Errorf("error expected for %s", h)
+		}
+	}
+}
+
+func BenchmarkGraphIdArrayScanText(b *testing.B) {
+	gids := make([]GraphId, 1000)
+	for i := range gids {
+		gids[i], _ = NewGraphIdFromParts(3, uint64(i+1))
+	}
+	src, _ := Array(gids).Value()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var out []GraphId
+		err := Array(&out).Scan(src)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGraphIdArrayParseBinary(b *testing.B) {
+	gids := make([]GraphId, 1000)
+	for i := range gids {
+		gids[i], _ = NewGraphIdFromParts(3, uint64(i+1))
+	}
+	src := AppendGraphIdArrayBinary(nil, gids, 7002)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := ParseGraphIdArrayBinary(src)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func TestServerGraphId(t *testing.T) {
 	skipUnlessServerTest(t)
 
-	db := mustOpenAndSetGraph(t)
+	db := mustOpenGraph(t)
 	defer db.Close()
 
 	_, err := db.Exec(`CREATE (:gid)`)
@@ -18,6 +18,7 @@ package ag
 
 import (
 	"bytes"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"strings"
@@ -81,14 +82,21 @@ func readPath(b []byte) (advance int, ds []interface{}, err error) {
 	advance = 1
 
 	read, readNext := readVertexElement, readEdgeElement
-	for b[advance] != byte(']') {
+	for {
+		if advance >= len(b) {
+			err = fmt.Errorf("bad graphpath representation: %s", b)
+			return
+		}
+		if b[advance] == byte(']') {
+			break
+		}
 		if len(ds) > 0 {
 			// remove comma
 			advance++
 		}
 
 		n, d, r := read(b[advance:])
-		if err != nil {
+		if r != nil {
 			err = errors.New("invalid path element: " + r.Error())
 			return
 		}
@@ -107,6 +115,60 @@ func readPath(b []byte) (advance int, ds []interface{}, err error) {
 	return
 }
 
+// PathLoader is an interface used by ValuePath.
+type PathLoader interface {
+	// LoadPath returns a path to pass it to the database driver.
+	//
+	// valid is false if the path is NULL.
+	//
+	// es is a series of connected vertices and edges. It must start with a
+	// vertex, and vertices and edges must alternate. es is ignored if valid
+	// is false.
+	LoadPath() (valid bool, es []EntityLoader)
+}
+
+// ValuePath returns the text representation of a path given by calling
+// LoadPath as a database/sql/driver Value. If the path is NULL, it returns nil.
+//
+// An error will be returned if vertices and edges of the path do not
+// alternate or if any of them is NULL.
+func ValuePath(loader PathLoader) (driver.Value, error) {
+	valid, es := loader.LoadPath()
+	if !valid {
+		return nil, nil
+	}
+
+	n := len(es)
+	if n > 0 && n%2 == 0 {
+		return nil, fmt.Errorf("invalid number of path elements: %d", n)
+	}
+
+	for i, e := range es {
+		valid, core := e.LoadEntity()
+		if !valid {
+			return nil, fmt.Errorf("invalid path element at %d: NULL", i)
+		}
+
+		var ok bool
+		if i%2 == 0 {
+			_, ok = core.(VertexCore)
+		} else {
+			_, ok = core.(EdgeCore)
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid path element at %d: %T", i, core)
+		}
+	}
+
+	b, err := appendEntityArray(nil, n, func(i int) EntityLoader {
+		return es[i]
+	})
+	if err != nil {
+		return nil, errors.New("invalid path: " + err.Error())
+	}
+	return b, nil
+}
+
 // BasicPath can be used to scan the value from the database driver as a path.
 //
 // This is a reference implementation that uses PathSaver and ScanPath.
@@ -173,7 +235,167 @@ func (p *BasicPath) SavePath(valid bool, ds []interface{}) error {
 	return nil
 }
 
+// LoadPath implements PathLoader interface.
+func (p BasicPath) LoadPath() (valid bool, es []EntityLoader) {
+	if !p.Valid {
+		return false, nil
+	}
+
+	nv, ne := len(p.Vertices), len(p.Edges)
+	if nv < 1 && ne < 1 {
+		return true, []EntityLoader{}
+	}
+
+	es = make([]EntityLoader, 0, nv+ne)
+	for i := 0; i < nv || i < ne; i++ {
+		if i < nv {
+			es = append(es, p.Vertices[i])
+		}
+		if i < ne {
+			es = append(es, p.Edges[i])
+		}
+	}
+	return true, es
+}
+
 // Scan implements the database/sql Scanner interface. It calls ScanPath.
 func (p *BasicPath) Scan(src interface{}) error {
 	return ScanPath(src, p)
 }
+
+// Value implements the database/sql/driver Valuer interface. It calls
+// ValuePath.
+func (p BasicPath) Value() (driver.Value, error) {
+	return ValuePath(p)
+}
+
+// TypedPath can be used to scan the value from the database driver as a path
+// whose vertices and edges are V and E respectively.
+//
+// PV and PE are *V and *E, which must implement both Entity and EntityLoader.
+// Go cannot infer them, so they are given explicitly.
+//
+//	var p ag.TypedPath[ag.BasicVertex, *ag.BasicVertex, ag.BasicEdge, *ag.BasicEdge]
+type TypedPath[V any, PV interface {
+	*V
+	Entity
+	EntityLoader
+}, E any, PE interface {
+	*E
+	Entity
+	EntityLoader
+}] struct {
+	Valid    bool
+	Vertices []V
+	Edges    []E
+}
+
+func (p TypedPath[V, PV, E, PE]) String() string {
+	if !p.Valid {
+		return "NULL"
+	}
+
+	nv, ne := len(p.Vertices), len(p.Edges)
+	if nv < 1 && ne < 1 {
+		return "[]"
+	}
+
+	s := make([]string, 0, nv+ne)
+	for i := 0; i < nv || i < ne; i++ {
+		if i < nv {
+			s = append(s, entityString(PV(&p.Vertices[i])))
+		}
+		if i < ne {
+			s = append(s, entityString(PE(&p.Edges[i])))
+		}
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(s, ","))
+}
+
+// SavePath implements PathSaver interface.
+func (p *TypedPath[V, PV, E, PE]) SavePath(valid bool, ds []interface{}) error {
+	p.Valid = valid
+	p.Vertices, p.Edges = nil, nil
+	if !valid {
+		return nil
+	}
+
+	n := len(ds)
+	if n < 1 {
+		return nil
+	}
+
+	ne := n / 2
+	p.Vertices = make([]V, ne+1)
+	if ne > 0 {
+		p.Edges = make([]E, ne)
+	}
+
+	for i, d := range ds {
+		var e Entity
+		if i%2 == 0 {
+			e = PV(&p.Vertices[i/2])
+		} else {
+			e = PE(&p.Edges[i/2])
+		}
+
+		err := ScanEntity(d, e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Scan implements the database/sql Scanner interface. It calls ScanPath.
+func (p *TypedPath[V, PV, E, PE]) Scan(src interface{}) error {
+	return ScanPath(src, p)
+}
+
+// Value implements the database/sql/driver Valuer interface. It calls
+// ValuePath.
+func (p TypedPath[V, PV, E, PE]) Value() (driver.Value, error) {
+	if !p.Valid {
+		return nil, nil
+	}
+
+	nv, ne := len(p.Vertices), len(p.Edges)
+	es := make(loadedPath, 0, nv+ne)
+	for i := 0; i < nv || i < ne; i++ {
+		if i < nv {
+			es = append(es, PV(&p.Vertices[i]))
+		}
+		if i < ne {
+			es = append(es, PE(&p.Edges[i]))
+		}
+	}
+
+	return ValuePath(es)
+}
+
+// entityString returns the result of String of *ptr if it has one. Otherwise,
+// it returns the text representation of the entity.
+func entityString[T any, PT interface {
+	*T
+	EntityLoader
+}](ptr PT) string {
+	if s, ok := interface{}(*ptr).(fmt.Stringer); ok {
+		return s.String()
+	}
+	if s, ok := interface{}(ptr).(fmt.Stringer); ok {
+		return s.String()
+	}
+	if b, err := appendEntity(nil, ptr); err == nil {
+		return string(b)
+	}
+	return fmt.Sprint(*ptr)
+}
+
+// loadedPath is a non-NULL path whose elements are already loaded.
+type loadedPath []EntityLoader
+
+func (p loadedPath) LoadPath() (valid bool, es []EntityLoader) {
+	return true, p
+}
@@ -16,7 +16,10 @@ limitations under the License.
 
 package ag
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+)
 
 func TestBasicPathScanNil(t *testing.T) {
 	var p BasicPath
@@ -72,10 +75,182 @@ func TestBasicPathScan(t *testing.T) {
 	}
 }
 
+func TestBasicPathValue(t *testing.T) {
+	tests := []struct {
+		src []byte
+		val []byte
+	}{
+		{nil, nil},
+		{[]byte("[]"), []byte("[]")},
+		{[]byte(`[v[3.1]{}]`), []byte(`[v[3.1]{}]`)},
+		{
+			[]byte(`[v[3.1]{"n": 1},e[4.1][3.1,3.2]{},v[3.2]{}]`),
+			[]byte(`[v[3.1]{"n":1},e[4.1][3.1,3.2]{},v[3.2]{}]`),
+		},
+	}
+	for _, c := range tests {
+		var p BasicPath
+		var src interface{}
+		if c.src != nil {
+			src = c.src
+		}
+		err := p.Scan(src)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		val, err := p.Value()
+		if err != nil {
+			t.Error(err)
+		} else if c.val == nil {
+			if val != nil {
+				t.Errorf("got %s, want nil", val)
+			}
+		} else if b, ok := val.([]byte); !ok || !bytes.Equal(b, c.val) {
+			t.Errorf("got %s, want %s", val, c.val)
+		}
+
+		if val == nil {
+			continue
+		}
+		var q BasicPath
+		err = q.Scan(val)
+		if err != nil {
+			t.Error(err)
+		} else if q.String() != p.String() {
+			t.Errorf("got %s, want %s", q, p)
+		}
+	}
+}
+
+func TestBasicPathValueError(t *testing.T) {
+	var v BasicVertex
+	var e BasicEdge
+	_ = v.Scan([]byte(`v[3.1]{}`))
+	_ = e.Scan([]byte(`e[4.1][3.1,3.2]{}`))
+
+	tests := []BasicPath{
+		{true, []BasicVertex{v, v}, nil},
+		{true, []BasicVertex{v}, []BasicEdge{e}},
+		{true, []BasicVertex{v, v}, []BasicEdge{e, e}},
+		{true, nil, []BasicEdge{e}},
+		{true, []BasicVertex{{}}, nil},
+		{true, []BasicVertex{v, v}, []BasicEdge{{}}},
+	}
+	for _, p := range tests {
+		_, err := p.Value()
+		if err == nil {
+			t.Errorf("error expected for %v", p)
+		}
+	}
+}
+
+type testPath []EntityLoader
+
+func (p testPath) LoadPath() (bool, []EntityLoader) {
+	return true, p
+}
+
+func TestValuePath(t *testing.T) {
+	var v BasicVertex
+	var e BasicEdge
+	_ = v.Scan([]byte(`v[3.1]{}`))
+	_ = e.Scan([]byte(`e[4.1][3.1,3.1]{}`))
+
+	val, err := ValuePath(testPath{v, e, v})
+	if err != nil {
+		t.Error(err)
+	} else if want := `[v[3.1]{},e[4.1][3.1,3.1]{},v[3.1]{}]`; string(val.([]byte)) != want {
+		t.Errorf("got %s, want %s", val, want)
+	}
+
+	_, err = ValuePath(testPath{e, v, e})
+	if err == nil {
+		t.Error("error expected for a path starting with an edge")
+	}
+}
+
+type testKnows struct {
+	EdgeHeader `json:"-"`
+	Since      int `json:"since"`
+}
+
+func (e *testKnows) Scan(src interface{}) error {
+	return ScanEntity(src, e)
+}
+
+func TestTypedPathScan(t *testing.T) {
+	tests := []struct {
+		b  []byte
+		nv int
+		ne int
+	}{
+		{[]byte("[]"), 0, 0},
+		{[]byte(`[v[3.1]{"name": "a"},e[4.1][3.1,3.2]{"since": 1},v[3.2]{"name": "b"},NULL,NULL]`), 3, 2},
+	}
+	for _, c := range tests {
+		var p TypedPath[TypedVertex[typedVertexProps], *TypedVertex[typedVertexProps], testKnows, *testKnows]
+		err := p.Scan(c.b)
+		if err != nil {
+			t.Error(err)
+		} else if !p.Valid {
+			t.Errorf("got NULL, want Valid %T", p)
+		} else {
+			if nv := len(p.Vertices); nv != c.nv {
+				t.Errorf("got len(p.Vertices) == %d, want %d", nv, c.nv)
+			} else if ne := len(p.Edges); ne != c.ne {
+				t.Errorf("got len(p.Edges) == %d, want %d", ne, c.ne)
+			} else if nv > 0 && (p.Vertices[1].Properties.Name != "b" || p.Edges[0].Since != 1) {
+				t.Errorf("got %v", p)
+			}
+		}
+	}
+
+	var p TypedPath[TypedVertex[typedVertexProps], *TypedVertex[typedVertexProps], testKnows, *testKnows]
+	err := p.Scan(nil)
+	if err != nil {
+		t.Error(err)
+	} else if p.Valid {
+		t.Errorf("got %s, want NULL", p)
+	}
+}
+
+func TestTypedPathValue(t *testing.T) {
+	b := []byte(`[v[3.1]{"name": "a"},e[4.1][3.1,3.2]{"since": 1},v[3.2]{"name": "b"}]`)
+	var p TypedPath[TypedVertex[typedVertexProps], *TypedVertex[typedVertexProps], testKnows, *testKnows]
+	err := p.Scan(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `[v[3.1]{"name":"a"},e[4.1][3.1,3.2]{"since":1},v[3.2]{"name":"b"}]`
+	if s := p.String(); s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+	val, err := p.Value()
+	if err != nil {
+		t.Error(err)
+	} else if string(val.([]byte)) != want {
+		t.Errorf("got %s, want %s", val, want)
+	}
+
+	// String does not fail for a path with as many edges as vertices
+	p.Edges = append(p.Edges, p.Edges[0])
+	want = `[v[3.1]{"name":"a"},e[4.1][3.1,3.2]{"since":1},v[3.2]{"name":"b"},e[4.1][3.1,3.2]{"since":1}]`
+	if s := p.String(); s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+	_, err = p.Value()
+	if err == nil {
+		t.Error("error expected for even number of path elements")
+	}
+}
+
 func TestServerGraphpath(t *testing.T) {
 	skipUnlessServerTest(t)
 
-	db := mustOpenAndSetGraph(t)
+	db := mustOpenGraph(t)
 	defer db.Close()
 
 	_, err := db.Exec(`CREATE (:pv)-[:pe]->(:pv)-[:pe]->(:pv)`)
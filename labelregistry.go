@@ -0,0 +1,182 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultLabelReloadInterval is the default ReloadInterval of LabelRegistry.
+const DefaultLabelReloadInterval = time.Second
+
+// LabelRegistry is a cache of the labels of a graph. It loads all the labels
+// once on first use and reloads them on Refresh or when a label is not
+// found, at most once per ReloadInterval, so labels created later are
+// resolved as well. It is safe for concurrent use.
+type LabelRegistry struct {
+	// ReloadInterval is the minimum interval between the reloads caused by
+	// labels that are not found. Until it passes after a load, such labels
+	// are reported as not found without querying the server. Refresh is not
+	// limited. If it is not positive, DefaultLabelReloadInterval is used. It
+	// must be set before the registry is used.
+	ReloadInterval time.Duration
+
+	q     Queryer
+	graph string
+	now   func() time.Time // for tests
+
+	mu       sync.RWMutex
+	loaded   bool
+	loadedAt time.Time
+	gen      uint64 // incremented on every load
+	byId     map[uint16]*Label
+	byName   map[string]*Label
+
+	loadMu sync.Mutex // serializes loads
+}
+
+// NewLabelRegistry returns LabelRegistry of graph that reads labels through
+// q.
+func NewLabelRegistry(q Queryer, graph string) *LabelRegistry {
+	return &LabelRegistry{q: q, graph: graph, now: time.Now}
+}
+
+// Refresh reloads all the labels.
+func (r *LabelRegistry) Refresh(ctx context.Context) error {
+	r.mu.RLock()
+	gen := r.gen
+	r.mu.RUnlock()
+
+	return r.load(ctx, gen, true)
+}
+
+// load loads the labels unless another load has finished since gen was read.
+// If force is false, the labels are loaded only if they have never been.
+func (r *LabelRegistry) load(ctx context.Context, gen uint64, force bool) error {
+	r.loadMu.Lock()
+	defer r.loadMu.Unlock()
+
+	r.mu.RLock()
+	done := r.gen != gen || (!force && r.loaded)
+	r.mu.RUnlock()
+	if done {
+		return nil
+	}
+
+	labels, err := Labels(ctx, r.q, r.graph)
+	if err != nil {
+		return err
+	}
+
+	byId := make(map[uint16]*Label, len(labels))
+	byName := make(map[string]*Label, len(labels))
+	for i := range labels {
+		l := &labels[i]
+		byId[l.Id] = l
+		byName[l.Name] = l
+	}
+
+	r.mu.Lock()
+	r.loaded, r.loadedAt, r.byId, r.byName = true, r.now(), byId, byName
+	r.gen++
+	r.mu.Unlock()
+
+	return nil
+}
+
+// lookup finds a label with find, loading the labels if they have never been
+// loaded or the label is missing and ReloadInterval has passed since the last
+// load. The result does not share Parents with the cache.
+func (r *LabelRegistry) lookup(ctx context.Context, find func() *Label) (Label, error) {
+	interval := r.ReloadInterval
+	if interval <= 0 {
+		interval = DefaultLabelReloadInterval
+	}
+
+	for refreshed := false; ; refreshed = true {
+		r.mu.RLock()
+		loaded, loadedAt, gen := r.loaded, r.loadedAt, r.gen
+		var l Label
+		found := false
+		if loaded {
+			if p := find(); p != nil {
+				l, found = *p, true
+				l.Parents = slices.Clone(p.Parents)
+			}
+		}
+		r.mu.RUnlock()
+
+		if found {
+			return l, nil
+		}
+		if refreshed || (loaded && r.now().Sub(loadedAt) < interval) {
+			return Label{}, ErrLabelNotFound
+		}
+
+		err := r.load(ctx, gen, loaded)
+		if err != nil {
+			return Label{}, err
+		}
+	}
+}
+
+// Label returns the label whose ID is labelId. It returns ErrLabelNotFound if
+// there is no such label even after reloading the labels.
+func (r *LabelRegistry) Label(ctx context.Context, labelId uint16) (Label, error) {
+	return r.lookup(ctx, func() *Label {
+		return r.byId[labelId]
+	})
+}
+
+// LabelByName returns the label whose name is name. It returns
+// ErrLabelNotFound if there is no such label even after reloading the labels.
+func (r *LabelRegistry) LabelByName(ctx context.Context, name string) (Label, error) {
+	return r.lookup(ctx, func() *Label {
+		return r.byName[name]
+	})
+}
+
+// LabelId returns the ID of the label whose name is name.
+func (r *LabelRegistry) LabelId(ctx context.Context, name string) (uint16, error) {
+	l, err := r.LabelByName(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	return l.Id, nil
+}
+
+// LabelOf returns the label of gid.
+func (r *LabelRegistry) LabelOf(ctx context.Context, gid GraphId) (Label, error) {
+	if !gid.Valid {
+		return Label{}, errors.New("invalid graphid: NULL")
+	}
+	return r.Label(ctx, gid.LabelId())
+}
+
+// LabelName returns the name of the label of gid using reg. The labels are
+// loaded with context.Background if reg has to load them.
+func (gid GraphId) LabelName(reg *LabelRegistry) (string, error) {
+	l, err := reg.LabelOf(context.Background(), gid)
+	if err != nil {
+		return "", err
+	}
+	return l.Name, nil
+}
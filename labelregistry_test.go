@@ -0,0 +1,167 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLabelRegistry(t *testing.T) {
+	var mu sync.Mutex
+	labels := [][]driver.Value{
+		{"g", "person", "v", int64(3), []byte(`["base"]`)},
+	}
+	var loads int32
+
+	db, d := newFakeDB()
+	defer db.Close()
+	d.query = func(query string, args []driver.NamedValue) (driver.Rows, error) {
+		atomic.AddInt32(&loads, 1)
+		mu.Lock()
+		defer mu.Unlock()
+		return &fakeRows{labelColumns, append([][]driver.Value(nil), labels...)}, nil
+	}
+
+	ctx := context.Background()
+	reg := NewLabelRegistry(db, "g")
+	now := time.Now()
+	reg.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		name, err := mustNewGraphId("3.1").LabelName(reg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "person" {
+			t.Errorf("got %s, want person", name)
+		}
+	}
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Errorf("got %d loads, want 1", n)
+	}
+
+	// the result does not share Parents with the cache
+	l, err := reg.Label(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Parents[0] = "x"
+	l, _ = reg.Label(ctx, 3)
+	if len(l.Parents) != 1 || l.Parents[0] != "base" {
+		t.Errorf("got %v, want [base]", l.Parents)
+	}
+
+	// a miss does not reload the labels until ReloadInterval passes
+	mu.Lock()
+	labels = append(labels, []driver.Value{"g", "knows", "e", int64(4), []byte(`[]`)})
+	mu.Unlock()
+
+	_, err = reg.LabelId(ctx, "knows")
+	if err != ErrLabelNotFound {
+		t.Errorf("got %v, want %v", err, ErrLabelNotFound)
+	}
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Errorf("got %d loads, want 1", n)
+	}
+
+	now = now.Add(DefaultLabelReloadInterval)
+	id, err := reg.LabelId(ctx, "knows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 4 {
+		t.Errorf("got %d, want 4", id)
+	}
+	l, err = reg.Label(ctx, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "knows" || l.Kind != EdgeLabel {
+		t.Errorf("got %v", l)
+	}
+	if n := atomic.LoadInt32(&loads); n != 2 {
+		t.Errorf("got %d loads, want 2", n)
+	}
+
+	// unknown IDs do not hit the server on every call
+	for i := 0; i < 3; i++ {
+		_, err = reg.Label(ctx, uint16(5+i))
+		if err != ErrLabelNotFound {
+			t.Errorf("got %v, want %v", err, ErrLabelNotFound)
+		}
+	}
+	if n := atomic.LoadInt32(&loads); n != 2 {
+		t.Errorf("got %d loads, want 2", n)
+	}
+	now = now.Add(DefaultLabelReloadInterval)
+	_, err = reg.Label(ctx, 5)
+	if err != ErrLabelNotFound {
+		t.Errorf("got %v, want %v", err, ErrLabelNotFound)
+	}
+	if n := atomic.LoadInt32(&loads); n != 3 {
+		t.Errorf("got %d loads, want 3", n)
+	}
+
+	err = reg.Refresh(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&loads); n != 4 {
+		t.Errorf("got %d loads, want 4", n)
+	}
+
+	_, err = mustNewGraphId("NULL").LabelName(reg)
+	if err == nil {
+		t.Error("error expected for NULL")
+	}
+}
+
+func TestLabelRegistryConcurrent(t *testing.T) {
+	var loads int32
+	db, d := newFakeDB()
+	defer db.Close()
+	d.query = func(query string, args []driver.NamedValue) (driver.Rows, error) {
+		atomic.AddInt32(&loads, 1)
+		return &fakeRows{labelColumns, [][]driver.Value{
+			{"g", "person", "v", int64(3), []byte(`[]`)},
+		}}, nil
+	}
+
+	reg := NewLabelRegistry(db, "g")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name, err := mustNewGraphId("3.1").LabelName(reg)
+			if err != nil || name != "person" {
+				t.Errorf("got %q, %v", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&loads); n != 1 {
+		t.Errorf("got %d loads, want 1", n)
+	}
+}
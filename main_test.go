@@ -17,14 +17,13 @@ limitations under the License.
 package ag
 
 import (
-	"database/sql"
+	"context"
 	"flag"
-	"fmt"
 	"log"
 	"os"
 	"testing"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var (
@@ -52,14 +51,14 @@ func setUpServerTest() {
 	os.Setenv("PGDATABASE", "postgres")
 	os.Setenv("PGSSLMODE", "disable")
 
-	db, err := sql.Open("postgres", "")
+	base, err := pq.NewConnector("")
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer db.Close()
+	g := NewGraph(base, agTestGraphName)
+	defer g.Close()
 
-	q := fmt.Sprintf(`CREATE GRAPH IF NOT EXISTS %q`, agTestGraphName)
-	_, err = db.Exec(q)
+	err = g.Create(context.Background())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -70,11 +69,11 @@ func tearDownServerTest() {
 		return
 	}
 
-	db, err := sql.Open("postgres", "")
+	base, err := pq.NewConnector("")
 	if err == nil {
-		q := fmt.Sprintf(`DROP GRAPH %q CASCADE`, agTestGraphName)
-		db.Exec(q)
-		db.Close()
+		g := NewGraph(base, agTestGraphName)
+		g.Drop(context.Background(), true)
+		g.Close()
 	}
 }
 
@@ -84,15 +83,12 @@ func skipUnlessServerTest(t *testing.T) {
 	}
 }
 
-func mustOpenAndSetGraph(t *testing.T) *sql.DB {
-	db, err := sql.Open("postgres", "")
+// mustOpenGraph returns Graph for the test graph. graph_path set on *sql.DB
+// would affect only one of the pooled connections.
+func mustOpenGraph(t *testing.T) *Graph {
+	base, err := pq.NewConnector("")
 	if err != nil {
 		t.Fatal(err)
 	}
-	q := fmt.Sprintf(`SET graph_path = %q`, agTestGraphName)
-	_, err = db.Exec(q)
-	if err != nil {
-		t.Fatal(err)
-	}
-	return db
+	return NewGraph(base, agTestGraphName)
 }
@@ -0,0 +1,443 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memgraph
+
+import (
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+)
+
+// The algorithms below work on the vertices in the graph only. Edges whose
+// start or end vertex is not in the graph are ignored. Neighbors of a vertex
+// are visited in the order of the IDs of the edges to them, and vertices that
+// are not ordered otherwise are taken in the order of their IDs, so the results
+// are deterministic.
+
+// Direction is the direction of the edges to follow from a vertex.
+type Direction int
+
+const (
+	// Outgoing follows edges from their start vertices to their end vertices.
+	Outgoing Direction = iota
+	// Incoming follows edges from their end vertices to their start vertices.
+	Incoming
+	// Undirected follows edges in both directions.
+	Undirected
+)
+
+// step is an edge followed from a vertex to vertex to.
+type step struct {
+	edge ag.GraphIdKey
+	to   ag.GraphIdKey
+}
+
+// steps returns the edges of label to follow from vertex k in dir in the order
+// of the edge IDs. If label is empty, edges of all labels are followed.
+func (g *Graph) steps(k ag.GraphIdKey, dir Direction, label string) []step {
+	var out, in []ag.GraphIdKey
+	if dir == Outgoing || dir == Undirected {
+		out = adjacentKeys(g.out, k, label)
+	}
+	if dir == Incoming || dir == Undirected {
+		in = adjacentKeys(g.in, k, label)
+	}
+
+	ss := make([]step, 0, len(out)+len(in))
+	for len(out) > 0 || len(in) > 0 {
+		var s step
+		if len(in) < 1 || (len(out) > 0 && out[0] <= in[0]) {
+			s.edge, out = out[0], out[1:]
+			s.to = g.edges[s.edge].End.Key()
+		} else {
+			s.edge, in = in[0], in[1:]
+			s.to = g.edges[s.edge].Start.Key()
+		}
+		if _, ok := g.vertices[s.to]; ok {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+// BFS returns an iterator over the vertices reachable from the vertex whose ID
+// is start, including start, in breadth-first order. It yields the ID of each
+// vertex and its depth from start. Edges of label are followed in dir. If
+// label is empty, edges of all labels are followed.
+func (g *Graph) BFS(start ag.GraphId, dir Direction, label string) iter.Seq2[ag.GraphId, int] {
+	return func(yield func(ag.GraphId, int) bool) {
+		s := start.Key()
+		if _, ok := g.vertices[s]; !ok {
+			return
+		}
+
+		type item struct {
+			k     ag.GraphIdKey
+			depth int
+		}
+		visited := map[ag.GraphIdKey]bool{s: true}
+		queue := []item{{s, 0}}
+		for len(queue) > 0 {
+			it := queue[0]
+			queue = queue[1:]
+			if !yield(g.vertices[it.k].Id, it.depth) {
+				return
+			}
+
+			for _, st := range g.steps(it.k, dir, label) {
+				if !visited[st.to] {
+					visited[st.to] = true
+					queue = append(queue, item{st.to, it.depth + 1})
+				}
+			}
+		}
+	}
+}
+
+// DFS is the same as BFS except that it visits the vertices in depth-first
+// order. A vertex is yielded before the vertices reached from it.
+func (g *Graph) DFS(start ag.GraphId, dir Direction, label string) iter.Seq2[ag.GraphId, int] {
+	return func(yield func(ag.GraphId, int) bool) {
+		s := start.Key()
+		if _, ok := g.vertices[s]; !ok {
+			return
+		}
+
+		type item struct {
+			k     ag.GraphIdKey
+			depth int
+		}
+		visited := map[ag.GraphIdKey]bool{}
+		stack := []item{{s, 0}}
+		for len(stack) > 0 {
+			it := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if visited[it.k] {
+				continue
+			}
+			visited[it.k] = true
+			if !yield(g.vertices[it.k].Id, it.depth) {
+				return
+			}
+
+			// push in reverse so that the first neighbor is visited first
+			ss := g.steps(it.k, dir, label)
+			for i := len(ss) - 1; i >= 0; i-- {
+				if !visited[ss[i].to] {
+					stack = append(stack, item{ss[i].to, it.depth + 1})
+				}
+			}
+		}
+	}
+}
+
+// WeightFunc returns the weight of an edge for Dijkstra.
+type WeightFunc func(e ag.BasicEdge) float64
+
+// PropertyWeight returns WeightFunc that returns the number in property key of
+// an edge. If the edge does not have the property or it is not a number, def
+// is returned.
+func PropertyWeight(key string, def float64) WeightFunc {
+	return func(e ag.BasicEdge) float64 {
+		switch w := e.Properties[key].(type) {
+		case float64:
+			return w
+		case json.Number:
+			if f, err := w.Float64(); err == nil {
+				return f
+			}
+		}
+		return def
+	}
+}
+
+// ShortestPaths is the result of Dijkstra.
+type ShortestPaths struct {
+	g     *Graph
+	start ag.GraphIdKey
+	dist  map[ag.GraphIdKey]float64
+	prev  map[ag.GraphIdKey]step // the edge to each vertex from the previous one
+}
+
+type distItem struct {
+	k    ag.GraphIdKey
+	dist float64
+}
+
+// distHeap orders items by distance and then by ID.
+type distHeap []distItem
+
+func (h distHeap) Len() int { return len(h) }
+func (h distHeap) Less(i, j int) bool {
+	if h[i].dist != h[j].dist {
+		return h[i].dist < h[j].dist
+	}
+	return h[i].k < h[j].k
+}
+func (h distHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *distHeap) Push(x interface{}) { *h = append(*h, x.(distItem)) }
+func (h *distHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// Dijkstra finds the shortest paths from the vertex whose ID is start to the
+// vertices reachable from it. Edges of label are followed in dir, and the
+// length of an edge is given by weight. If label is empty, edges of all
+// labels are followed. If weight is nil, every edge has length 1.
+//
+// Of the paths of the same length, the one found first is taken. It returns
+// an error if the weight of an edge is negative or NaN.
+func (g *Graph) Dijkstra(start ag.GraphId, dir Direction, label string, weight WeightFunc) (*ShortestPaths, error) {
+	s := start.Key()
+	p := &ShortestPaths{g, s, map[ag.GraphIdKey]float64{}, map[ag.GraphIdKey]step{}}
+	if _, ok := g.vertices[s]; !ok {
+		return p, nil
+	}
+
+	done := map[ag.GraphIdKey]bool{}
+	p.dist[s] = 0
+	h := &distHeap{{s, 0}}
+	for h.Len() > 0 {
+		it := heap.Pop(h).(distItem)
+		if done[it.k] {
+			continue
+		}
+		done[it.k] = true
+
+		for _, st := range g.steps(it.k, dir, label) {
+			w := 1.0
+			if weight != nil {
+				e := g.edges[st.edge]
+				w = weight(e)
+				if w < 0 || math.IsNaN(w) {
+					return nil, fmt.Errorf("invalid weight of edge %s: %v", e.Id, w)
+				}
+			}
+
+			d := it.dist + w
+			if old, ok := p.dist[st.to]; !ok || d < old {
+				p.dist[st.to] = d
+				p.prev[st.to] = step{st.edge, it.k}
+				heap.Push(h, distItem{st.to, d})
+			}
+		}
+	}
+	return p, nil
+}
+
+// Distance returns the length of the shortest path to the vertex whose ID is
+// id. ok is false if the vertex is not reachable.
+func (p *ShortestPaths) Distance(id ag.GraphId) (dist float64, ok bool) {
+	dist, ok = p.dist[id.Key()]
+	return
+}
+
+// PathTo returns the shortest path to the vertex whose ID is id. ok is false
+// if the vertex is not reachable.
+func (p *ShortestPaths) PathTo(id ag.GraphId) (path ag.BasicPath, ok bool) {
+	k := id.Key()
+	if _, ok = p.dist[k]; !ok {
+		return ag.BasicPath{}, false
+	}
+
+	vs := []ag.BasicVertex{p.g.vertices[k]}
+	var es []ag.BasicEdge
+	for k != p.start {
+		st := p.prev[k]
+		es = append(es, p.g.edges[st.edge])
+		k = st.to
+		vs = append(vs, p.g.vertices[k])
+	}
+	slices.Reverse(vs)
+	slices.Reverse(es)
+
+	return ag.BasicPath{Valid: true, Vertices: vs, Edges: es}, true
+}
+
+// ConnectedComponents returns the weakly connected components of g. The IDs in
+// a component are in order and the components are in the order of their first
+// IDs.
+func (g *Graph) ConnectedComponents() [][]ag.GraphId {
+	var comps [][]ag.GraphId
+	seen := map[ag.GraphIdKey]bool{}
+	for _, k := range g.vertexIds.keys() {
+		if seen[k] {
+			continue
+		}
+
+		var comp []ag.GraphId
+		for id := range g.BFS(g.vertices[k].Id, Undirected, "") {
+			seen[id.Key()] = true
+			comp = append(comp, id)
+		}
+		slices.SortFunc(comp, ag.GraphId.Compare)
+		comps = append(comps, comp)
+	}
+	return comps
+}
+
+// ErrCycle is returned by TopologicalSort if the graph has a cycle.
+var ErrCycle = errors.New("graph has a cycle")
+
+type keyHeap []ag.GraphIdKey
+
+func (h keyHeap) Len() int            { return len(h) }
+func (h keyHeap) Less(i, j int) bool  { return h[i] < h[j] }
+func (h keyHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *keyHeap) Push(x interface{}) { *h = append(*h, x.(ag.GraphIdKey)) }
+func (h *keyHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// TopologicalSort returns the IDs of the vertices of g so that the start
+// vertex of every edge comes before its end vertex. Of the vertices that can
+// come next, the one with the smallest ID is taken. It returns ErrCycle if g
+// has a cycle.
+func (g *Graph) TopologicalSort() ([]ag.GraphId, error) {
+	vertexIds := g.vertexIds.keys()
+	indeg := make(map[ag.GraphIdKey]int, len(vertexIds))
+	for _, k := range vertexIds {
+		for _, st := range g.steps(k, Outgoing, "") {
+			indeg[st.to]++
+		}
+	}
+
+	h := &keyHeap{}
+	for _, k := range vertexIds {
+		if indeg[k] == 0 {
+			*h = append(*h, k)
+		}
+	}
+	// vertexIds is sorted, so h is already a heap
+
+	ids := make([]ag.GraphId, 0, len(vertexIds))
+	for h.Len() > 0 {
+		k := heap.Pop(h).(ag.GraphIdKey)
+		ids = append(ids, g.vertices[k].Id)
+		for _, st := range g.steps(k, Outgoing, "") {
+			indeg[st.to]--
+			if indeg[st.to] == 0 {
+				heap.Push(h, st.to)
+			}
+		}
+	}
+
+	if len(ids) < len(vertexIds) {
+		return nil, ErrCycle
+	}
+	return ids, nil
+}
+
+// Score is a score of a vertex given by PageRank and DegreeCentrality.
+type Score struct {
+	Id    ag.GraphId
+	Score float64
+}
+
+// PageRank returns the PageRank of every vertex of g in the order of the IDs.
+// damping is the damping factor, usually 0.85. Iterations stop when the sum of
+// the changes of the ranks is less than tolerance or after maxIterations.
+//
+// Edges are counted as many times as they appear between two vertices. The
+// rank of a vertex without outgoing edges is distributed to all the vertices.
+func (g *Graph) PageRank(damping float64, maxIterations int, tolerance float64) []Score {
+	vertexIds := g.vertexIds.keys()
+	n := len(vertexIds)
+	if n < 1 {
+		return nil
+	}
+
+	index := make(map[ag.GraphIdKey]int, n)
+	for i, k := range vertexIds {
+		index[k] = i
+	}
+	// sources of the incoming edges of each vertex and the number of the
+	// outgoing edges of each vertex
+	in := make([][]int, n)
+	outdeg := make([]int, n)
+	for i, k := range vertexIds {
+		for _, st := range g.steps(k, Outgoing, "") {
+			j := index[st.to]
+			in[j] = append(in[j], i)
+			outdeg[i]++
+		}
+	}
+
+	rank := make([]float64, n)
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+	next := make([]float64, n)
+	for it := 0; it < maxIterations; it++ {
+		dangling := 0.0
+		for i, r := range rank {
+			if outdeg[i] == 0 {
+				dangling += r
+			}
+		}
+
+		base := (1-damping)/float64(n) + damping*dangling/float64(n)
+		diff := 0.0
+		for j := range next {
+			sum := 0.0
+			for _, i := range in[j] {
+				sum += rank[i] / float64(outdeg[i])
+			}
+			next[j] = base + damping*sum
+			diff += math.Abs(next[j] - rank[j])
+		}
+		rank, next = next, rank
+
+		if diff < tolerance {
+			break
+		}
+	}
+
+	scores := make([]Score, n)
+	for i, k := range vertexIds {
+		scores[i] = Score{g.vertices[k].Id, rank[i]}
+	}
+	return scores
+}
+
+// DegreeCentrality returns the number of the edges of every vertex of g in dir
+// divided by the number of the other vertices, in the order of the IDs. An
+// edge from a vertex to itself is counted twice if dir is Undirected.
+func (g *Graph) DegreeCentrality(dir Direction) []Score {
+	vertexIds := g.vertexIds.keys()
+	n := len(vertexIds)
+	scores := make([]Score, n)
+	for i, k := range vertexIds {
+		scores[i].Id = g.vertices[k].Id
+		if n > 1 {
+			scores[i].Score = float64(len(g.steps(k, dir, ""))) / float64(n-1)
+		}
+	}
+	return scores
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memgraph
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+)
+
+// newAlgorithmGraph returns a graph of vertices 3.1 to 3.6. 3.1 to 3.4 are
+// connected, 3.5 has an edge to 3.9 which is not in the graph, and 3.6 has no
+// edges.
+func newAlgorithmGraph(t *testing.T) *Graph {
+	g := New()
+
+	var vs []ag.BasicVertex
+	mustScan(t, ag.Array(&vs), `[v[3.1]{},v[3.2]{},v[3.3]{},v[3.4]{},v[3.5]{},v[3.6]{}]`)
+	var es []ag.BasicEdge
+	mustScan(t, ag.Array(&es), `[e[4.1][3.1,3.2]{"w": 1},e[4.2][3.1,3.3]{"w": 4},e[4.3][3.2,3.3]{"w": 1},e[4.4][3.3,3.4]{"w": 1},f[5.1][3.2,3.4]{},e[4.5][3.5,3.9]{"w": 1}]`)
+
+	for _, x := range []interface{}{vs, es} {
+		err := g.Add(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+func visits(seq iter.Seq2[ag.GraphId, int]) string {
+	var s []string
+	for id, depth := range seq {
+		s = append(s, fmt.Sprintf("%s:%d", id, depth))
+	}
+	return strings.Join(s, " ")
+}
+
+func TestTraversal(t *testing.T) {
+	g := newAlgorithmGraph(t)
+	id := mustNewGraphId
+
+	tests := []struct {
+		seq  iter.Seq2[ag.GraphId, int]
+		want string
+	}{
+		{g.BFS(id("3.1"), Outgoing, ""), "3.1:0 3.2:1 3.3:1 3.4:2"},
+		{g.BFS(id("3.1"), Outgoing, "f"), "3.1:0"},
+		{g.BFS(id("3.4"), Incoming, ""), "3.4:0 3.3:1 3.2:1 3.1:2"},
+		{g.BFS(id("3.3"), Undirected, "e"), "3.3:0 3.1:1 3.2:1 3.4:1"},
+		{g.BFS(id("3.5"), Undirected, ""), "3.5:0"},
+		{g.BFS(id("3.9"), Outgoing, ""), ""},
+		{g.DFS(id("3.1"), Outgoing, ""), "3.1:0 3.2:1 3.3:2 3.4:3"},
+		{g.DFS(id("3.4"), Incoming, ""), "3.4:0 3.3:1 3.1:2 3.2:2"},
+		{g.DFS(id("3.1"), Outgoing, "f"), "3.1:0"},
+	}
+	for i, c := range tests {
+		if got := visits(c.seq); got != c.want {
+			t.Errorf("%d: got %s, want %s", i, got, c.want)
+		}
+	}
+
+	n := 0
+	for range g.BFS(id("3.1"), Outgoing, "") {
+		n++
+		if n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("got %d visits, want 2", n)
+	}
+}
+
+func TestDijkstra(t *testing.T) {
+	g := newAlgorithmGraph(t)
+	id := mustNewGraphId
+
+	p, err := g.Dijkstra(id("3.1"), Outgoing, "", PropertyWeight("w", 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		id    string
+		dist  float64
+		edges string
+	}{
+		{"3.1", 0, ""},
+		{"3.2", 1, "4.1"},
+		{"3.3", 2, "4.1 4.3"},
+		{"3.4", 3, "4.1 4.3 4.4"},
+	} {
+		d, ok := p.Distance(id(c.id))
+		if !ok || d != c.dist {
+			t.Errorf("%s: got %v, %t, want %v", c.id, d, ok, c.dist)
+		}
+		path, ok := p.PathTo(id(c.id))
+		if !ok || edgeIds(path.Edges) != c.edges {
+			t.Errorf("%s: got %v, %t, want %s", c.id, path, ok, c.edges)
+		}
+		if len(path.Vertices) != len(path.Edges)+1 || !path.Vertices[len(path.Edges)].Id.Equal(id(c.id)) {
+			t.Errorf("%s: got %v", c.id, path)
+		}
+	}
+	if _, ok := p.Distance(id("3.5")); ok {
+		t.Error("unexpected distance to 3.5")
+	}
+	if _, ok := p.PathTo(id("3.5")); ok {
+		t.Error("unexpected path to 3.5")
+	}
+
+	// the path found first is taken among the ones of the same length
+	p, err = g.Dijkstra(id("3.1"), Outgoing, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, _ := p.PathTo(id("3.4"))
+	if got, want := edgeIds(path.Edges), "4.1 5.1"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	p, err = g.Dijkstra(id("3.4"), Incoming, "e", PropertyWeight("w", 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, _ = p.PathTo(id("3.1"))
+	if got, want := vertexIds(path.Vertices), "3.4 3.3 3.2 3.1"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	_, err = g.Dijkstra(id("3.1"), Outgoing, "", PropertyWeight("w", -1))
+	if err == nil {
+		t.Error("error expected for negative weight")
+	}
+}
+
+func componentIds(comps [][]ag.GraphId) string {
+	s := make([]string, len(comps))
+	for i, comp := range comps {
+		s[i] = fmt.Sprint(comp)
+	}
+	return strings.Join(s, " ")
+}
+
+func TestConnectedComponents(t *testing.T) {
+	g := newAlgorithmGraph(t)
+	if got, want := componentIds(g.ConnectedComponents()), "[3.1 3.2 3.3 3.4] [3.5] [3.6]"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got := New().ConnectedComponents(); len(got) != 0 {
+		t.Errorf("got %v, want none", got)
+	}
+}
+
+func TestTopologicalSort(t *testing.T) {
+	g := New()
+	var es []ag.BasicEdge
+	mustScan(t, ag.Array(&es), `[e[4.1][3.3,3.1]{},e[4.2][3.4,3.2]{},e[4.3][3.1,3.2]{}]`)
+	for _, k := range []string{"3.4", "3.3", "3.2", "3.1"} {
+		var v ag.BasicVertex
+		mustScan(t, &v, "v["+k+"]{}")
+		g.AddVertex(v)
+	}
+	g.Add(es)
+
+	ids, err := g.TopologicalSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(ids), "[3.3 3.1 3.4 3.2]"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	var e ag.BasicEdge
+	mustScan(t, &e, `e[4.4][3.2,3.3]{}`)
+	g.AddEdge(e)
+	_, err = g.TopologicalSort()
+	if err != ErrCycle {
+		t.Errorf("got %v, want %v", err, ErrCycle)
+	}
+}
+
+func scores(ss []Score) string {
+	s := make([]string, len(ss))
+	for i, x := range ss {
+		s[i] = fmt.Sprintf("%s:%.2f", x.Id, x.Score)
+	}
+	return strings.Join(s, " ")
+}
+
+func TestPageRank(t *testing.T) {
+	g := New()
+	var es []ag.BasicEdge
+	mustScan(t, ag.Array(&es), `[e[4.1][3.1,3.2]{},e[4.2][3.2,3.3]{},e[4.3][3.3,3.1]{}]`)
+	g.Add(es)
+	var vs []ag.BasicVertex
+	mustScan(t, ag.Array(&vs), `[v[3.1]{},v[3.2]{},v[3.3]{}]`)
+	g.Add(vs)
+
+	if got, want := scores(g.PageRank(0.85, 100, 1e-9)), "3.1:0.33 3.2:0.33 3.3:0.33"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	g = newAlgorithmGraph(t)
+	ss := g.PageRank(0.85, 100, 1e-9)
+	sum := 0.0
+	for _, s := range ss {
+		sum += s.Score
+	}
+	if math.Abs(sum-1) > 1e-6 {
+		t.Errorf("got sum %v, want 1", sum)
+	}
+	// 3.4 is reached by the most paths and 3.1 by none
+	hi, lo := ss[0], ss[0]
+	for _, s := range ss[:4] {
+		if s.Score > hi.Score {
+			hi = s
+		}
+		if s.Score < lo.Score {
+			lo = s
+		}
+	}
+	if hi.Id.String() != "3.4" || lo.Id.String() != "3.1" {
+		t.Errorf("got %s", scores(ss))
+	}
+
+	if got := scores(g.PageRank(0.85, 100, 1e-9)); got != scores(ss) {
+		t.Errorf("got %s, want %s", got, scores(ss))
+	}
+	if got := New().PageRank(0.85, 100, 1e-9); len(got) != 0 {
+		t.Errorf("got %v, want none", got)
+	}
+}
+
+func TestDegreeCentrality(t *testing.T) {
+	g := newAlgorithmGraph(t)
+
+	tests := []struct {
+		dir  Direction
+		want string
+	}{
+		{Outgoing, "3.1:0.40 3.2:0.40 3.3:0.20 3.4:0.00 3.5:0.00 3.6:0.00"},
+		{Incoming, "3.1:0.00 3.2:0.20 3.3:0.40 3.4:0.40 3.5:0.00 3.6:0.00"},
+		{Undirected, "3.1:0.40 3.2:0.60 3.3:0.60 3.4:0.40 3.5:0.00 3.6:0.00"},
+	}
+	for _, c := range tests {
+		if got := scores(g.DegreeCentrality(c.dir)); got != c.want {
+			t.Errorf("%d: got %s, want %s", c.dir, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package memgraph keeps vertices and edges scanned from AgensGraph in memory
+as a graph so that the result of queries can be walked in Go.
+
+Vertices, edges and paths from many rows merge into one Graph. Entities are
+identified by their GraphIds, and edges are wired up to vertices by the start
+and end IDs of the edges.
+
+	g := memgraph.New()
+	for rows.Next() {
+		var p ag.BasicPath
+		err = rows.Scan(&p)
+		g.AddPath(p)
+	}
+	for _, e := range g.OutEdges(id, "knows") {
+		v, ok := g.Vertex(e.End)
+	}
+
+The methods that return many entities return them in the order of their
+GraphIds, so the results are deterministic.
+
+Graph also has algorithms such as BFS, Dijkstra, ConnectedComponents,
+TopologicalSort and PageRank, which run on the result of queries without
+another round trip to the server.
+*/
+package memgraph
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+)
+
+// Graph is an in-memory graph of vertices and edges. An edge may refer to
+// vertices that are not in the graph. The zero value is not ready for use; use
+// New. Graph is not safe for concurrent modification.
+type Graph struct {
+	vertices map[ag.GraphIdKey]ag.BasicVertex
+	edges    map[ag.GraphIdKey]ag.BasicEdge
+
+	vertexIds    keyList
+	edgeIds      keyList
+	vertexLabels map[string]*keyList
+	edgeLabels   map[string]*keyList
+
+	// edges of each vertex by label
+	out map[ag.GraphIdKey]map[string]*keyList
+	in  map[ag.GraphIdKey]map[string]*keyList
+}
+
+// New returns an empty Graph.
+func New() *Graph {
+	return &Graph{
+		vertices:     map[ag.GraphIdKey]ag.BasicVertex{},
+		edges:        map[ag.GraphIdKey]ag.BasicEdge{},
+		vertexLabels: map[string]*keyList{},
+		edgeLabels:   map[string]*keyList{},
+		out:          map[ag.GraphIdKey]map[string]*keyList{},
+		in:           map[ag.GraphIdKey]map[string]*keyList{},
+	}
+}
+
+// keyList is a list of distinct keys in ascending order. Keys are inserted
+// in place, so reading the list never changes it.
+type keyList struct {
+	ks []ag.GraphIdKey
+}
+
+// add inserts k, which must not be in l.
+func (l *keyList) add(k ag.GraphIdKey) {
+	i, _ := slices.BinarySearch(l.ks, k)
+	l.ks = slices.Insert(l.ks, i, k)
+}
+
+// remove removes k from l if l has it.
+func (l *keyList) remove(k ag.GraphIdKey) {
+	if i, ok := slices.BinarySearch(l.ks, k); ok {
+		l.ks = slices.Delete(l.ks, i, i+1)
+	}
+}
+
+// keys returns the keys of l in ascending order. l may be nil.
+func (l *keyList) keys() []ag.GraphIdKey {
+	if l == nil {
+		return nil
+	}
+	return l.ks
+}
+
+func addKey(lists map[string]*keyList, label string, k ag.GraphIdKey) {
+	l, ok := lists[label]
+	if !ok {
+		l = &keyList{}
+		lists[label] = l
+	}
+	l.add(k)
+}
+
+// removeKey removes k from the list of label and drops the list if it becomes
+// empty.
+func removeKey(lists map[string]*keyList, label string, k ag.GraphIdKey) {
+	l, ok := lists[label]
+	if !ok {
+		return
+	}
+	l.remove(k)
+	if len(l.ks) < 1 {
+		delete(lists, label)
+	}
+}
+
+// AddVertex adds v to g. If g already has a vertex whose ID is the ID of v, v
+// replaces it. A NULL vertex is ignored.
+func (g *Graph) AddVertex(v ag.BasicVertex) {
+	if !v.Valid || !v.Id.Valid {
+		return
+	}
+
+	k := v.Id.Key()
+	if old, ok := g.vertices[k]; !ok {
+		g.vertexIds.add(k)
+		addKey(g.vertexLabels, v.Label, k)
+	} else if old.Label != v.Label {
+		removeKey(g.vertexLabels, old.Label, k)
+		addKey(g.vertexLabels, v.Label, k)
+	}
+	g.vertices[k] = v
+}
+
+// AddEdge adds e to g. If g already has an edge whose ID is the ID of e, e
+// replaces it. A NULL edge is ignored.
+func (g *Graph) AddEdge(e ag.BasicEdge) {
+	if !e.Valid || !e.Id.Valid {
+		return
+	}
+
+	k := e.Id.Key()
+	old, ok := g.edges[k]
+	if !ok {
+		g.edgeIds.add(k)
+	} else if old.Label == e.Label && old.Start.Equal(e.Start) && old.End.Equal(e.End) {
+		g.edges[k] = e
+		return
+	} else {
+		removeKey(g.edgeLabels, old.Label, k)
+		if old.Start.Valid {
+			removeAdjacent(g.out, old.Start.Key(), old.Label, k)
+		}
+		if old.End.Valid {
+			removeAdjacent(g.in, old.End.Key(), old.Label, k)
+		}
+	}
+
+	addKey(g.edgeLabels, e.Label, k)
+	if e.Start.Valid {
+		addAdjacent(g.out, e.Start.Key(), e.Label, k)
+	}
+	if e.End.Valid {
+		addAdjacent(g.in, e.End.Key(), e.Label, k)
+	}
+	g.edges[k] = e
+}
+
+func addAdjacent(adj map[ag.GraphIdKey]map[string]*keyList, v ag.GraphIdKey, label string, e ag.GraphIdKey) {
+	byLabel, ok := adj[v]
+	if !ok {
+		byLabel = map[string]*keyList{}
+		adj[v] = byLabel
+	}
+	addKey(byLabel, label, e)
+}
+
+func removeAdjacent(adj map[ag.GraphIdKey]map[string]*keyList, v ag.GraphIdKey, label string, e ag.GraphIdKey) {
+	byLabel, ok := adj[v]
+	if !ok {
+		return
+	}
+	removeKey(byLabel, label, e)
+	if len(byLabel) < 1 {
+		delete(adj, v)
+	}
+}
+
+// AddPath adds the vertices and the edges of p to g. A NULL path is ignored.
+func (g *Graph) AddPath(p ag.BasicPath) {
+	if !p.Valid {
+		return
+	}
+	for _, v := range p.Vertices {
+		g.AddVertex(v)
+	}
+	for _, e := range p.Edges {
+		g.AddEdge(e)
+	}
+}
+
+// Add adds x to g. x is a vertex, an edge, a path, a pointer to one of them or
+// a slice of one of them, which are what can be scanned from a column.
+func (g *Graph) Add(x interface{}) error {
+	switch x := x.(type) {
+	case ag.BasicVertex:
+		g.AddVertex(x)
+	case *ag.BasicVertex:
+		g.AddVertex(*x)
+	case []ag.BasicVertex:
+		for _, v := range x {
+			g.AddVertex(v)
+		}
+	case ag.BasicEdge:
+		g.AddEdge(x)
+	case *ag.BasicEdge:
+		g.AddEdge(*x)
+	case []ag.BasicEdge:
+		for _, e := range x {
+			g.AddEdge(e)
+		}
+	case ag.BasicPath:
+		g.AddPath(x)
+	case *ag.BasicPath:
+		g.AddPath(*x)
+	case []ag.BasicPath:
+		for _, p := range x {
+			g.AddPath(p)
+		}
+	default:
+		return fmt.Errorf("invalid type for memgraph: %T", x)
+	}
+	return nil
+}
+
+// Merge adds all the vertices and edges of h to g.
+func (g *Graph) Merge(h *Graph) {
+	for _, k := range h.vertexIds.keys() {
+		g.AddVertex(h.vertices[k])
+	}
+	for _, k := range h.edgeIds.keys() {
+		g.AddEdge(h.edges[k])
+	}
+}
+
+// VertexCount returns the number of vertices in g.
+func (g *Graph) VertexCount() int {
+	return len(g.vertexIds.ks)
+}
+
+// EdgeCount returns the number of edges in g.
+func (g *Graph) EdgeCount() int {
+	return len(g.edgeIds.ks)
+}
+
+// Vertex returns the vertex whose ID is id.
+func (g *Graph) Vertex(id ag.GraphId) (ag.BasicVertex, bool) {
+	v, ok := g.vertices[id.Key()]
+	return v, ok
+}
+
+// Edge returns the edge whose ID is id.
+func (g *Graph) Edge(id ag.GraphId) (ag.BasicEdge, bool) {
+	e, ok := g.edges[id.Key()]
+	return e, ok
+}
+
+func (g *Graph) vertexList(ks []ag.GraphIdKey) []ag.BasicVertex {
+	vs := make([]ag.BasicVertex, len(ks))
+	for i, k := range ks {
+		vs[i] = g.vertices[k]
+	}
+	return vs
+}
+
+func (g *Graph) edgeList(ks []ag.GraphIdKey) []ag.BasicEdge {
+	es := make([]ag.BasicEdge, len(ks))
+	for i, k := range ks {
+		es[i] = g.edges[k]
+	}
+	return es
+}
+
+// Vertices returns all the vertices in g.
+func (g *Graph) Vertices() []ag.BasicVertex {
+	return g.vertexList(g.vertexIds.keys())
+}
+
+// Edges returns all the edges in g.
+func (g *Graph) Edges() []ag.BasicEdge {
+	return g.edgeList(g.edgeIds.keys())
+}
+
+// VerticesByLabel returns the vertices of label.
+func (g *Graph) VerticesByLabel(label string) []ag.BasicVertex {
+	return g.vertexList(g.vertexLabels[label].keys())
+}
+
+// EdgesByLabel returns the edges of label.
+func (g *Graph) EdgesByLabel(label string) []ag.BasicEdge {
+	return g.edgeList(g.edgeLabels[label].keys())
+}
+
+// adjacent returns the edges of vertex id in adj. If label is empty, the
+// edges of all labels are returned.
+func (g *Graph) adjacent(adj map[ag.GraphIdKey]map[string]*keyList, id ag.GraphId, label string) []ag.BasicEdge {
+	return g.edgeList(adjacentKeys(adj, id.Key(), label))
+}
+
+// adjacentKeys returns the keys of the edges of vertex k in adj in order. If
+// label is empty, the keys of the edges of all labels are returned.
+func adjacentKeys(adj map[ag.GraphIdKey]map[string]*keyList, k ag.GraphIdKey, label string) []ag.GraphIdKey {
+	byLabel := adj[k]
+	if label != "" {
+		return byLabel[label].keys()
+	}
+	if len(byLabel) == 1 {
+		for _, l := range byLabel {
+			return l.keys()
+		}
+	}
+
+	var ks []ag.GraphIdKey
+	for _, l := range byLabel {
+		ks = append(ks, l.ks...)
+	}
+	slices.Sort(ks)
+	return ks
+}
+
+// OutEdges returns the edges that start from the vertex whose ID is id. If
+// label is not empty, only the edges of label are returned.
+func (g *Graph) OutEdges(id ag.GraphId, label string) []ag.BasicEdge {
+	return g.adjacent(g.out, id, label)
+}
+
+// InEdges returns the edges that end at the vertex whose ID is id. If label is
+// not empty, only the edges of label are returned.
+func (g *Graph) InEdges(id ag.GraphId, label string) []ag.BasicEdge {
+	return g.adjacent(g.in, id, label)
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memgraph
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+)
+
+func mustScan(t *testing.T, dest interface{ Scan(interface{}) error }, src string) {
+	t.Helper()
+	err := dest.Scan([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustNewGraphId(str string) ag.GraphId {
+	gid, err := ag.NewGraphId(str)
+	if err != nil {
+		panic(err)
+	}
+	return gid
+}
+
+func vertexIds(vs []ag.BasicVertex) string {
+	ids := make([]string, len(vs))
+	for i, v := range vs {
+		ids[i] = v.Id.String()
+	}
+	return strings.Join(ids, " ")
+}
+
+func edgeIds(es []ag.BasicEdge) string {
+	ids := make([]string, len(es))
+	for i, e := range es {
+		ids[i] = e.Id.String()
+	}
+	return strings.Join(ids, " ")
+}
+
+// newTestGraph returns a graph built from a path, an array of vertices and
+// single edges that overlap.
+func newTestGraph(t *testing.T) *Graph {
+	g := New()
+
+	var p ag.BasicPath
+	mustScan(t, &p, `[person[3.2]{"name": "b"},knows[4.2][3.2,3.1]{},person[3.1]{"name": "a"}]`)
+	var vs []ag.BasicVertex
+	mustScan(t, ag.Array(&vs), `[person[3.1]{"name": "a2"},city[5.1]{}]`)
+	var e1, e2, e3 ag.BasicEdge
+	mustScan(t, &e1, `knows[4.1][3.1,3.2]{}`)
+	mustScan(t, &e2, `lives[6.1][3.1,5.1]{}`)
+	mustScan(t, &e3, `knows[4.1][3.1,3.2]{"since": 1}`)
+
+	for _, x := range []interface{}{p, vs, &e1, e2, []ag.BasicEdge{e3}, ag.BasicVertex{}} {
+		err := g.Add(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+func TestGraph(t *testing.T) {
+	g := newTestGraph(t)
+
+	if g.VertexCount() != 3 || g.EdgeCount() != 3 {
+		t.Errorf("got %d vertices and %d edges, want 3 and 3", g.VertexCount(), g.EdgeCount())
+	}
+	if got, want := vertexIds(g.Vertices()), "3.1 3.2 5.1"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := edgeIds(g.Edges()), "4.1 4.2 6.1"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// later values replace earlier ones
+	v, ok := g.Vertex(mustNewGraphId("3.1"))
+	if !ok || v.Properties["name"] != "a2" {
+		t.Errorf("got %v, %t", v, ok)
+	}
+	e, ok := g.Edge(mustNewGraphId("4.1"))
+	if !ok || fmt.Sprint(e.Properties["since"]) != "1" {
+		t.Errorf("got %v, %t", e, ok)
+	}
+	if _, ok = g.Vertex(mustNewGraphId("3.3")); ok {
+		t.Error("unexpected vertex 3.3")
+	}
+
+	if got, want := vertexIds(g.VerticesByLabel("person")), "3.1 3.2"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := edgeIds(g.EdgesByLabel("knows")), "4.1 4.2"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got := g.VerticesByLabel("none"); len(got) != 0 {
+		t.Errorf("got %v, want none", got)
+	}
+}
+
+func TestGraphAdjacency(t *testing.T) {
+	g := newTestGraph(t)
+	a := mustNewGraphId("3.1")
+
+	tests := []struct {
+		edges []ag.BasicEdge
+		want  string
+	}{
+		{g.OutEdges(a, ""), "4.1 6.1"},
+		{g.OutEdges(a, "knows"), "4.1"},
+		{g.OutEdges(a, "lives"), "6.1"},
+		{g.OutEdges(a, "none"), ""},
+		{g.InEdges(a, ""), "4.2"},
+		{g.InEdges(mustNewGraphId("5.1"), "lives"), "6.1"},
+		{g.InEdges(mustNewGraphId("5.1"), "knows"), ""},
+	}
+	for i, c := range tests {
+		if got := edgeIds(c.edges); got != c.want {
+			t.Errorf("%d: got %s, want %s", i, got, c.want)
+		}
+	}
+
+	for _, e := range g.OutEdges(a, "") {
+		if !e.Start.Equal(a) {
+			t.Errorf("got %v, want start %s", e, a)
+		}
+	}
+}
+
+func TestGraphMerge(t *testing.T) {
+	g := New()
+	var e ag.BasicEdge
+	mustScan(t, &e, `knows[4.3][3.2,3.3]{}`)
+	g.AddEdge(e)
+
+	g.Merge(newTestGraph(t))
+	if got, want := edgeIds(g.OutEdges(mustNewGraphId("3.2"), "knows")), "4.2 4.3"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if g.VertexCount() != 3 || g.EdgeCount() != 4 {
+		t.Errorf("got %d vertices and %d edges, want 3 and 4", g.VertexCount(), g.EdgeCount())
+	}
+}
+
+func TestGraphAddOutOfOrder(t *testing.T) {
+	g := New()
+	add := func(ids ...int) {
+		for _, i := range ids {
+			var v ag.BasicVertex
+			mustScan(t, &v, fmt.Sprintf(`person[3.%d]{}`, i))
+			g.AddVertex(v)
+
+			var e ag.BasicEdge
+			mustScan(t, &e, fmt.Sprintf(`knows[4.%d][3.1,3.%d]{}`, i, i))
+			g.AddEdge(e)
+		}
+	}
+
+	add(5, 2, 4)
+	if got, want := vertexIds(g.Vertices()), "3.2 3.4 3.5"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	add(1, 3, 2)
+	if got, want := vertexIds(g.VerticesByLabel("person")), "3.1 3.2 3.3 3.4 3.5"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := edgeIds(g.Edges()), "4.1 4.2 4.3 4.4 4.5"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := edgeIds(g.OutEdges(mustNewGraphId("3.1"), "")), "4.1 4.2 4.3 4.4 4.5"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if g.VertexCount() != 5 || g.EdgeCount() != 5 {
+		t.Errorf("got %d vertices and %d edges, want 5 and 5", g.VertexCount(), g.EdgeCount())
+	}
+}
+
+func TestGraphReplace(t *testing.T) {
+	g := New()
+	var v ag.BasicVertex
+	mustScan(t, &v, `person[3.1]{}`)
+	g.AddVertex(v)
+	mustScan(t, &v, `company[3.1]{}`)
+	g.AddVertex(v)
+	if vs := g.VerticesByLabel("person"); len(vs) != 0 {
+		t.Errorf("got %v, want no person", vs)
+	}
+	if got, want := vertexIds(g.VerticesByLabel("company")), "3.1"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	var e ag.BasicEdge
+	mustScan(t, &e, `knows[4.1][3.1,3.2]{}`)
+	g.AddEdge(e)
+	mustScan(t, &e, `likes[4.1][3.3,3.2]{}`)
+	g.AddEdge(e)
+	if es := g.OutEdges(mustNewGraphId("3.1"), ""); len(es) != 0 {
+		t.Errorf("got %v, want no edges from 3.1", es)
+	}
+	if es := g.EdgesByLabel("knows"); len(es) != 0 {
+		t.Errorf("got %v, want no knows", es)
+	}
+	if got, want := edgeIds(g.OutEdges(mustNewGraphId("3.3"), "likes")), "4.1"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, want := edgeIds(g.InEdges(mustNewGraphId("3.2"), "")), "4.1"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if g.VertexCount() != 1 || g.EdgeCount() != 1 {
+		t.Errorf("got %d vertices and %d edges, want 1 and 1", g.VertexCount(), g.EdgeCount())
+	}
+}
+
+func TestGraphAddError(t *testing.T) {
+	err := New().Add("v")
+	if err == nil {
+		t.Error("error expected")
+	}
+}
@@ -0,0 +1,271 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ogm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+	"github.com/bitnine-oss/agensgraph-golang/cypher"
+)
+
+// EdgeOption is an option of NewEdgeRepository.
+type EdgeOption func(*edgeOptions)
+
+type edgeOptions struct {
+	loadEndpoints bool
+}
+
+// LoadEndpoints makes EdgeRepository load the start and end vertices of edges
+// along with the edges. They are stored in the fields of the edge type tagged
+// with `ogm:"start"` and `ogm:"end"` respectively. The type of the fields must
+// be V or *V where *V implements ag.Entity, such as a struct that embeds
+// ag.VertexHeader. At least one of the fields must exist.
+//
+// The fields are not properties, so they should be excluded from properties
+// with `json:"-"` unless "ag" tags are used for the properties.
+func LoadEndpoints() EdgeOption {
+	return func(o *edgeOptions) {
+		o.loadEndpoints = true
+	}
+}
+
+// EdgeRepository creates, reads and deletes edges of type T, which is a struct
+// that embeds ag.EdgeHeader, directly or through ag.TrackedEdgeHeader, between
+// existing vertices.
+type EdgeRepository[T any] struct {
+	db    DB
+	label string
+
+	// index of the fields for the endpoints, nil if they are not loaded
+	start []int
+	end   []int
+}
+
+// NewEdgeRepository returns EdgeRepository of T that runs queries through db.
+// It returns an error if T does not embed ag.EdgeHeader, *T does not implement
+// ag.Entity or T has no label.
+func NewEdgeRepository[T any](db DB, opts ...EdgeOption) (*EdgeRepository[T], error) {
+	label, err := labelOf[T](reflect.TypeOf(ag.EdgeHeader{}))
+	if err != nil {
+		return nil, err
+	}
+
+	var o edgeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	r := &EdgeRepository[T]{db: db, label: label}
+	if o.loadEndpoints {
+		t := reflect.TypeOf((*T)(nil)).Elem()
+		for i, n := 0, t.NumField(); i < n; i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("ogm")
+			if tag != "start" && tag != "end" {
+				continue
+			}
+
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if f.PkgPath != "" || !reflect.PointerTo(ft).Implements(typeEntity) {
+				return nil, fmt.Errorf("invalid %s vertex field %s of %s", tag, f.Name, t)
+			}
+
+			if tag == "start" {
+				r.start = f.Index
+			} else {
+				r.end = f.Index
+			}
+		}
+		if r.start == nil && r.end == nil {
+			return nil, fmt.Errorf("%s has no start or end vertex field", t)
+		}
+	}
+	return r, nil
+}
+
+// Label returns the label of the edges of r.
+func (r *EdgeRepository[T]) Label() string {
+	return r.label
+}
+
+// WithDB returns a copy of r that runs queries through db. It can be used to
+// run queries in a transaction.
+func (r *EdgeRepository[T]) WithDB(db DB) *EdgeRepository[T] {
+	c := *r
+	c.db = db
+	return &c
+}
+
+// path returns (a)-[r:label]->(b) whose vertices have labels startLabel and
+// endLabel.
+func (r *EdgeRepository[T]) path(startLabel, endLabel string) cypher.PathPattern {
+	return cypher.Path(cypher.Node("a").Label(startLabel)).
+		Out(cypher.Rel("r").Label(r.label), cypher.Node("b").Label(endLabel))
+}
+
+// returnItems returns the edge and the endpoints to load.
+func (r *EdgeRepository[T]) returnItems() []interface{} {
+	items := []interface{}{"r"}
+	if r.start != nil {
+		items = append(items, "a")
+	}
+	if r.end != nil {
+		items = append(items, "b")
+	}
+	return items
+}
+
+// scan scans the edge and the endpoints returned by returnItems into e.
+func (r *EdgeRepository[T]) scan(rows *sql.Rows, e *T) error {
+	entity, err := entityOf(e)
+	if err != nil {
+		return err
+	}
+	l, err := entityLoaderOf(e)
+	if err != nil {
+		return err
+	}
+
+	es := []ag.Entity{entity}
+	ev := reflect.ValueOf(e).Elem()
+	if r.start != nil {
+		es = append(es, endpoint(ev.FieldByIndex(r.start)))
+	}
+	if r.end != nil {
+		es = append(es, endpoint(ev.FieldByIndex(r.end)))
+	}
+
+	err = scanEntities(rows, es...)
+	if err != nil {
+		return err
+	}
+	if len(es) < 2 {
+		return nil
+	}
+
+	valid, core := l.LoadEntity()
+	if !valid {
+		return nil
+	}
+	c, ok := core.(ag.EdgeCore)
+	if !ok {
+		return fmt.Errorf("invalid edge core: %T", core)
+	}
+	es = es[1:]
+	if r.start != nil {
+		err = checkEndpoint(es[0], c.Start)
+		es = es[1:]
+	}
+	if err == nil && r.end != nil {
+		err = checkEndpoint(es[0], c.End)
+	}
+	return err
+}
+
+// endpoint returns the entity to scan an endpoint into field f.
+func endpoint(f reflect.Value) ag.Entity {
+	if f.Kind() == reflect.Ptr {
+		v := reflect.New(f.Type().Elem())
+		f.Set(v)
+		return v.Interface().(ag.Entity)
+	}
+	return f.Addr().Interface().(ag.Entity)
+}
+
+// checkEndpoint reports an error if v is not the vertex whose ID is id. v is
+// not checked if it does not implement ag.EntityLoader.
+func checkEndpoint(v ag.Entity, id ag.GraphId) error {
+	l, ok := v.(ag.EntityLoader)
+	if !ok {
+		return nil
+	}
+	vid, err := idOf(l)
+	if err != nil {
+		return err
+	}
+	if !vid.Equal(id) {
+		return fmt.Errorf("got vertex %s for endpoint %s", vid, id)
+	}
+	return nil
+}
+
+// Connect creates an edge from the vertex whose ID is start to the vertex
+// whose ID is end with the properties of e, and stores the result in e. It
+// returns sql.ErrNoRows if either vertex does not exist.
+func (r *EdgeRepository[T]) Connect(ctx context.Context, start, end ag.GraphId, e *T) error {
+	props, err := propertiesOf(e)
+	if err != nil {
+		return err
+	}
+
+	qb := cypher.New().
+		Match(cypher.Node("a"), cypher.Node("b")).
+		Where(cypher.And(idEq("a", start), idEq("b", end))).
+		Create(cypher.Path(cypher.Node("a")).
+			Out(cypher.Rel("r").Label(r.label).Props(props), cypher.Node("b"))).
+		Return(r.returnItems()...)
+	return queryEntity(ctx, r.db, qb, e, r.scan)
+}
+
+// FindByID returns the edge whose ID is id. It returns sql.ErrNoRows if there
+// is no such edge.
+func (r *EdgeRepository[T]) FindByID(ctx context.Context, id ag.GraphId) (*T, error) {
+	var e T
+	qb := cypher.New().Match(r.path("", "")).Where(idEq("r", id)).Return(r.returnItems()...)
+	err := queryEntity(ctx, r.db, qb, &e, r.scan)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ListOutgoing returns the edges that start from the vertex whose ID is id in
+// the order of their IDs. If label is not empty, only the edges that end at
+// the vertices of label are returned.
+func (r *EdgeRepository[T]) ListOutgoing(ctx context.Context, id ag.GraphId, label string) ([]T, error) {
+	qb := cypher.New().
+		Match(r.path("", label)).
+		Where(idEq("a", id)).
+		Return(r.returnItems()...).
+		OrderBy(cypher.Func("id", cypher.Var("r")))
+	return queryEntities(ctx, r.db, qb, r.scan)
+}
+
+// ListIncoming returns the edges that end at the vertex whose ID is id in the
+// order of their IDs. If label is not empty, only the edges that start from
+// the vertices of label are returned.
+func (r *EdgeRepository[T]) ListIncoming(ctx context.Context, id ag.GraphId, label string) ([]T, error) {
+	qb := cypher.New().
+		Match(r.path(label, "")).
+		Where(idEq("b", id)).
+		Return(r.returnItems()...).
+		OrderBy(cypher.Func("id", cypher.Var("r")))
+	return queryEntities(ctx, r.db, qb, r.scan)
+}
+
+// Disconnect deletes the edge whose ID is id.
+func (r *EdgeRepository[T]) Disconnect(ctx context.Context, id ag.GraphId) error {
+	qb := cypher.New().Match(r.path("", "")).Where(idEq("r", id)).Delete("r")
+	return exec(ctx, r.db, qb)
+}
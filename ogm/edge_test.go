@@ -0,0 +1,155 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ogm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+)
+
+type knows struct {
+	ag.EdgeHeader `label:"knows"`
+	Since         int `ag:"since"`
+}
+
+type knowsPeople struct {
+	ag.EdgeHeader `label:"knows"`
+	Since         int     `ag:"since"`
+	From          *person `ogm:"start"`
+	To            person  `ogm:"end"`
+}
+
+func TestNewEdgeRepository(t *testing.T) {
+	_, err := NewEdgeRepository[person](nil)
+	if err == nil {
+		t.Error("error expected for vertex type")
+	}
+	_, err = NewEdgeRepository[knows](nil, LoadEndpoints())
+	if err == nil {
+		t.Error("error expected for no endpoint fields")
+	}
+
+	type trackedKnows struct {
+		ag.TrackedEdgeHeader `label:"knows"`
+	}
+	r, err := NewEdgeRepository[trackedKnows](nil)
+	if err != nil || r.Label() != "knows" {
+		t.Errorf("got %v, want knows", err)
+	}
+
+	type badEndpoint struct {
+		ag.EdgeHeader `label:"knows"`
+		From          string `ogm:"start"`
+	}
+	_, err = NewEdgeRepository[badEndpoint](nil, LoadEndpoints())
+	if err == nil {
+		t.Error("error expected for bad endpoint field")
+	}
+}
+
+func TestEdgeRepository(t *testing.T) {
+	db, d := newFakeDB(
+		[]string{`knows[4.1][3.1,3.2]{"since": 2010}`},
+		[]string{`knows[4.1][3.1,3.2]{"since": 2010}`, `knows[4.2][3.1,3.3]{}`},
+		nil,
+		nil,
+	)
+	defer db.Close()
+
+	r, err := NewEdgeRepository[knows](db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	a, _ := ag.NewGraphId("3.1")
+	b, _ := ag.NewGraphId("3.2")
+
+	e := &knows{Since: 2010}
+	err = r.Connect(ctx, a, b, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Valid || e.Id.String() != "4.1" || !e.Start.Equal(a) || !e.End.Equal(b) {
+		t.Errorf("got %+v", e)
+	}
+
+	es, err := r.ListOutgoing(ctx, a, "person")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(es) != 2 || es[1].End.String() != "3.3" {
+		t.Errorf("got %+v", es)
+	}
+
+	_, err = r.ListIncoming(ctx, b, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Disconnect(ctx, e.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkQueries(t, d,
+		`MATCH (a), (b) WHERE (id(a) = $1 AND id(b) = $2) CREATE (a)-[r:knows {since: $3::jsonb}]->(b) RETURN r ["3.1" "3.2" "2010"]`,
+		`MATCH (a)-[r:knows]->(b:person) WHERE id(a) = $1 RETURN r ORDER BY id(r) ["3.1"]`,
+		`MATCH (a)-[r:knows]->(b) WHERE id(b) = $1 RETURN r ORDER BY id(r) ["3.2"]`,
+		`MATCH (a)-[r:knows]->(b) WHERE id(r) = $1 DELETE r ["4.1"]`,
+	)
+}
+
+func TestEdgeRepositoryLoadEndpoints(t *testing.T) {
+	db, d := newFakeDB(
+		[]string{"knows[4.1][3.1,3.2]{}\tperson[3.1]{\"name\": \"alice\"}\tperson[3.2]{\"name\": \"bob\"}"},
+		[]string{"knows[4.1][3.1,3.2]{}\tperson[3.1]{}\tperson[3.3]{}"},
+		nil,
+	)
+	defer db.Close()
+
+	r, err := NewEdgeRepository[knowsPeople](db, LoadEndpoints())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	id, _ := ag.NewGraphId("4.1")
+
+	e, err := r.FindByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.From == nil || e.From.Name != "alice" || e.To.Name != "bob" {
+		t.Errorf("got %+v", e)
+	}
+
+	// the end vertex does not match EdgeCore.End
+	_, err = r.FindByID(ctx, id)
+	if err == nil {
+		t.Error("error expected for wrong endpoint")
+	}
+
+	_, err = r.FindByID(ctx, id)
+	if err != sql.ErrNoRows {
+		t.Errorf("got %v, want %v", err, sql.ErrNoRows)
+	}
+
+	q := `MATCH (a)-[r:knows]->(b) WHERE id(r) = $1 RETURN r, a, b ["4.1"]`
+	checkQueries(t, d, q, q, q)
+}
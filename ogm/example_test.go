@@ -0,0 +1,74 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ogm_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+	"github.com/bitnine-oss/agensgraph-golang/cypher"
+	"github.com/bitnine-oss/agensgraph-golang/ogm"
+	"github.com/lib/pq"
+)
+
+type Person struct {
+	ag.TrackedVertexHeader `label:"person"`
+	Name                   string `ag:"name"`
+	Age                    int    `ag:"age,omitempty"`
+}
+
+func ExampleRepository() {
+	base, err := pq.NewConnector("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	g := ag.NewGraph(base, "g")
+	defer g.Close()
+
+	ctx := context.Background()
+	people, err := ogm.NewRepository[Person](g)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	p := &Person{Name: "alice", Age: 30}
+	err = people.Create(ctx, p)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	p.Age++
+	err = people.Update(ctx, p)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	found, err := people.FindBy(ctx, cypher.Props{"name": "alice"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, p := range found {
+		fmt.Println(p.Id, p.Name, p.Age)
+	}
+
+	err = people.DetachDelete(ctx, p.Id)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package ogm maps Go structs to vertices and edges of AgensGraph and runs the
+Cypher queries for creating, reading, updating and deleting them.
+
+An entity type is a struct that embeds ag.VertexHeader for Repository or
+ag.EdgeHeader for EdgeRepository, directly or through another embedded struct
+such as ag.TrackedVertexHeader. Its label is given by the "label" key in the
+tag of the embedded field or by the EntityLabel method. Properties are
+written with ag.MarshalProperties and read with ag.ScanEntity, so the "ag" tags
+and PropertiesLoader/PropertiesSaver work as they do for ag.ValueEntity and
+ag.ScanEntity.
+
+	type Person struct {
+		ag.TrackedVertexHeader `label:"person"`
+		Name string            `ag:"name"`
+	}
+
+	people, err := ogm.NewRepository[Person](ag.NewGraph(base, "g"))
+	p := &Person{Name: "alice"}
+	err = people.Create(ctx, p)
+	// p.Id is the ID of the new vertex
+*/
+package ogm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+	"github.com/bitnine-oss/agensgraph-golang/cypher"
+)
+
+// DB is an interface used by Repository and EdgeRepository to run queries.
+// *ag.Graph and *sql.Tx started by BeginTx of *ag.Graph implement it.
+type DB interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Labeler is an interface used by Repository and EdgeRepository to get the
+// label of an entity type. It takes precedence over the "label" tag.
+type Labeler interface {
+	// EntityLabel returns the label of the entity type. It is called on the
+	// zero value.
+	EntityLabel() string
+}
+
+var (
+	typeEntity       = reflect.TypeOf((*ag.Entity)(nil)).Elem()
+	typeEntityLoader = reflect.TypeOf((*ag.EntityLoader)(nil)).Elem()
+)
+
+// labelOf returns the label of T, which must embed header.
+func labelOf[T any](header reflect.Type) (string, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return "", fmt.Errorf("%s is not a struct", t)
+	}
+	f, ok := headerField(t, header)
+	if !ok {
+		return "", fmt.Errorf("%s does not embed %s", t, header)
+	}
+	// the methods of header are not promoted if they are ambiguous
+	if pt := reflect.PointerTo(t); !pt.Implements(typeEntity) || !pt.Implements(typeEntityLoader) {
+		return "", fmt.Errorf("%s does not implement ag.Entity and ag.EntityLoader", pt)
+	}
+
+	if l, ok := any(new(T)).(Labeler); ok {
+		if label := l.EntityLabel(); label != "" {
+			return label, nil
+		}
+	}
+	if label := f.Tag.Get("label"); label != "" {
+		return label, nil
+	}
+	return "", fmt.Errorf("%s has no label", t)
+}
+
+// headerField returns the embedded field of struct t that is header or a
+// struct that embeds header.
+func headerField(t, header reflect.Type) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.Anonymous {
+			continue
+		}
+		if f.Type == header {
+			return f, true
+		}
+		if f.Type.Kind() == reflect.Struct {
+			if _, ok := headerField(f.Type, header); ok {
+				return f, true
+			}
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// propertiesOf returns the properties of e to write them.
+func propertiesOf(e interface{}) (cypher.Props, error) {
+	var b []byte
+	var err error
+	if p, ok := e.(ag.PropertiesLoader); ok {
+		b, err = p.LoadProperties()
+	} else {
+		b, err = ag.MarshalProperties(e)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]json.RawMessage
+	err = json.Unmarshal(b, &m)
+	if err != nil {
+		return nil, errors.New("invalid properties: " + err.Error())
+	}
+
+	props := make(cypher.Props, len(m))
+	for k, v := range m {
+		props[k] = v
+	}
+	return props, nil
+}
+
+// idOf returns the ID of e, which must not be NULL.
+func idOf(e ag.EntityLoader) (ag.GraphId, error) {
+	valid, core := e.LoadEntity()
+	if !valid {
+		return ag.GraphId{}, errors.New("invalid entity: NULL")
+	}
+
+	var id ag.GraphId
+	switch c := core.(type) {
+	case ag.VertexCore:
+		id = c.Id
+	case ag.EdgeCore:
+		id = c.Id
+	default:
+		return ag.GraphId{}, fmt.Errorf("invalid entity core: %T", core)
+	}
+	if !id.Valid {
+		return ag.GraphId{}, errors.New("invalid entity ID: NULL")
+	}
+	return id, nil
+}
+
+// idEq returns the condition that the ID of variable is id.
+func idEq(variable string, id ag.GraphId) cypher.Expr {
+	return cypher.Eq(cypher.Func("id", cypher.Var(variable)), id)
+}
+
+// scanEntities scans the columns of the current row of rows into es in order.
+func scanEntities(rows *sql.Rows, es ...ag.Entity) error {
+	bs := make([][]byte, len(es))
+	dest := make([]interface{}, len(es))
+	for i := range bs {
+		dest[i] = &bs[i]
+	}
+	err := rows.Scan(dest...)
+	if err != nil {
+		return err
+	}
+
+	for i, b := range bs {
+		if b == nil {
+			err = ag.ScanEntity(nil, es[i])
+		} else {
+			err = ag.ScanEntity(b, es[i])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entityOf returns e as ag.Entity.
+func entityOf(e interface{}) (ag.Entity, error) {
+	entity, ok := e.(ag.Entity)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement ag.Entity", e)
+	}
+	return entity, nil
+}
+
+// entityLoaderOf returns e as ag.EntityLoader.
+func entityLoaderOf(e interface{}) (ag.EntityLoader, error) {
+	l, ok := e.(ag.EntityLoader)
+	if !ok {
+		return nil, fmt.Errorf("%T does not implement ag.EntityLoader", e)
+	}
+	return l, nil
+}
+
+// scanEntity scans the only column of the current row of rows into e.
+func scanEntity[T any](rows *sql.Rows, e *T) error {
+	entity, err := entityOf(e)
+	if err != nil {
+		return err
+	}
+	return scanEntities(rows, entity)
+}
+
+// queryEntity runs a query built by qb and scans the only row it returns into
+// e with scan. It returns sql.ErrNoRows if the query returns no rows.
+func queryEntity[T any](ctx context.Context, db DB, qb *cypher.Builder, e *T, scan func(*sql.Rows, *T) error) error {
+	q, args, err := qb.Build()
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	err = scan(rows, e)
+	if err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// queryEntities runs a query built by qb and returns the rows it returns
+// scanned with scan.
+func queryEntities[T any](ctx context.Context, db DB, qb *cypher.Builder, scan func(*sql.Rows, *T) error) ([]T, error) {
+	q, args, err := qb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var es []T
+	for rows.Next() {
+		var e T
+		err = scan(rows, &e)
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, e)
+	}
+	return es, rows.Err()
+}
+
+// exec runs a query built by qb.
+func exec(ctx context.Context, db DB, qb *cypher.Builder) error {
+	q, args, err := qb.Build()
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, q, args...)
+	return err
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ogm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+)
+
+// fakeDriver records the queries and returns the rows of results in order.
+// The columns of a row are separated by tabs. A query returns no rows once
+// results is empty.
+type fakeDriver struct {
+	mu      sync.Mutex
+	queries []fakeQuery
+	results [][]string
+}
+
+type fakeQuery struct {
+	query string
+	args  []interface{}
+}
+
+func (q fakeQuery) String() string {
+	return fmt.Sprintf("%s %q", q.query, q.args)
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{d}, nil
+}
+
+func (d *fakeDriver) Connect(ctx context.Context) (driver.Conn, error) {
+	return d.Open("")
+}
+
+func (d *fakeDriver) Driver() driver.Driver {
+	return d
+}
+
+// run records query and returns the next result.
+func (d *fakeDriver) run(query string, args []driver.NamedValue) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	q := fakeQuery{query: query}
+	for _, a := range args {
+		q.args = append(q.args, a.Value)
+	}
+	d.queries = append(d.queries, q)
+
+	if len(d.results) < 1 {
+		return nil
+	}
+	r := d.results[0]
+	d.results = d.results[1:]
+	return r
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("Prepare not supported")
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("Begin not supported")
+}
+
+func (c fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.run(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{c.d.run(query, args)}, nil
+}
+
+type fakeRows struct {
+	values []string
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.values) < 1 {
+		return []string{"n"}
+	}
+	return strings.Split(r.values[0], "\t")
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) < 1 {
+		return io.EOF
+	}
+	for i, v := range strings.Split(r.values[0], "\t") {
+		dest[i] = []byte(v)
+	}
+	r.values = r.values[1:]
+	return nil
+}
+
+func newFakeDB(results ...[]string) (*sql.DB, *fakeDriver) {
+	d := &fakeDriver{results: results}
+	return sql.OpenDB(d), d
+}
+
+type taggedPerson struct {
+	ag.VertexHeader `label:"person"`
+}
+
+type labeledPerson struct {
+	ag.VertexHeader `label:"person"`
+}
+
+func (labeledPerson) EntityLabel() string {
+	return "Person"
+}
+
+type noLabel struct {
+	ag.VertexHeader
+}
+
+type trackedPerson struct {
+	ag.TrackedVertexHeader `label:"person"`
+}
+
+type base struct {
+	ag.VertexHeader
+}
+
+type embeddedPerson struct {
+	base `label:"person"`
+}
+
+// ambiguousPerson embeds two headers at the same depth, so it does not have
+// the methods of either.
+type ambiguousPerson struct {
+	base `label:"person"`
+	trackedPerson
+}
+
+func TestLabelOf(t *testing.T) {
+	vh := reflect.TypeOf(ag.VertexHeader{})
+	eh := reflect.TypeOf(ag.EdgeHeader{})
+
+	label, err := labelOf[taggedPerson](vh)
+	if err != nil || label != "person" {
+		t.Errorf("got %q, %v, want person", label, err)
+	}
+	label, err = labelOf[labeledPerson](vh)
+	if err != nil || label != "Person" {
+		t.Errorf("got %q, %v, want Person", label, err)
+	}
+
+	label, err = labelOf[trackedPerson](vh)
+	if err != nil || label != "person" {
+		t.Errorf("got %q, %v, want person", label, err)
+	}
+	label, err = labelOf[embeddedPerson](vh)
+	if err != nil || label != "person" {
+		t.Errorf("got %q, %v, want person", label, err)
+	}
+
+	if _, err = labelOf[ambiguousPerson](vh); err == nil {
+		t.Error("error expected for ambiguous headers")
+	}
+	if _, err = labelOf[noLabel](vh); err == nil {
+		t.Error("error expected for no label")
+	}
+	if _, err = labelOf[taggedPerson](eh); err == nil {
+		t.Error("error expected for vertex as edge")
+	}
+	if _, err = labelOf[ag.GraphId](vh); err == nil {
+		t.Error("error expected for no header")
+	}
+	if _, err = labelOf[int](vh); err == nil {
+		t.Error("error expected for non-struct")
+	}
+}
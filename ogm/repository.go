@@ -0,0 +1,158 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ogm
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+	"github.com/bitnine-oss/agensgraph-golang/cypher"
+)
+
+// Repository creates, reads, updates and deletes vertices of type T, which is
+// a struct that embeds ag.VertexHeader. T should embed ag.TrackedVertexHeader
+// for Update to write only the changed properties.
+type Repository[T any] struct {
+	db    DB
+	label string
+}
+
+// NewRepository returns Repository of T that runs queries through db. It
+// returns an error if T does not embed ag.VertexHeader, *T does not implement
+// ag.Entity or T has no label.
+func NewRepository[T any](db DB) (*Repository[T], error) {
+	label, err := labelOf[T](reflect.TypeOf(ag.VertexHeader{}))
+	if err != nil {
+		return nil, err
+	}
+	return &Repository[T]{db, label}, nil
+}
+
+// Label returns the label of the vertices of r.
+func (r *Repository[T]) Label() string {
+	return r.label
+}
+
+// WithDB returns a copy of r that runs queries through db. It can be used to
+// run queries in a transaction.
+func (r *Repository[T]) WithDB(db DB) *Repository[T] {
+	return &Repository[T]{db, r.label}
+}
+
+func (r *Repository[T]) node() cypher.NodePattern {
+	return cypher.Node("n").Label(r.label)
+}
+
+// Create creates a vertex with the properties of v and stores the result,
+// including the ID of the new vertex, in v.
+func (r *Repository[T]) Create(ctx context.Context, v *T) error {
+	props, err := propertiesOf(v)
+	if err != nil {
+		return err
+	}
+
+	qb := cypher.New().Create(r.node().Props(props)).Return("n")
+	return queryEntity(ctx, r.db, qb, v, scanEntity[T])
+}
+
+// FindByID returns the vertex whose ID is id. It returns sql.ErrNoRows if there
+// is no such vertex.
+func (r *Repository[T]) FindByID(ctx context.Context, id ag.GraphId) (*T, error) {
+	var v T
+	qb := cypher.New().Match(r.node()).Where(idEq("n", id)).Return("n")
+	err := queryEntity(ctx, r.db, qb, &v, scanEntity[T])
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// FindBy returns the vertices whose properties match filter in the order of
+// their IDs. If filter is nil, all the vertices of the label are returned.
+func (r *Repository[T]) FindBy(ctx context.Context, filter cypher.Props) ([]T, error) {
+	qb := cypher.New().
+		Match(r.node().Props(filter)).
+		Return("n").
+		OrderBy(cypher.Func("id", cypher.Var("n")))
+	return queryEntities(ctx, r.db, qb, scanEntity[T])
+}
+
+// Update writes the properties of v to the vertex whose ID is the ID of v and
+// stores the result in v. It returns sql.ErrNoRows if there is no such vertex.
+//
+// If T embeds ag.TrackedVertexHeader and v has been scanned, only the
+// properties changed since then are set or removed as given by ag.Changes.
+// Otherwise, the properties of v are set one by one. Either way, the other
+// properties of the vertex are kept.
+func (r *Repository[T]) Update(ctx context.Context, v *T) error {
+	l, err := entityLoaderOf(v)
+	if err != nil {
+		return err
+	}
+	id, err := idOf(l)
+	if err != nil {
+		return err
+	}
+
+	qb := cypher.New().Match(r.node()).Where(idEq("n", id))
+	d, err := ag.Changes(l)
+	switch err {
+	case nil:
+		qb.SetChanges("n", d)
+	case ag.ErrNoSnapshot:
+		props, err := propertiesOf(v)
+		if err != nil {
+			return err
+		}
+		if items := assignments("n", props); len(items) > 0 {
+			qb.Set(items...)
+		}
+	default:
+		return err
+	}
+	qb.Return("n")
+	return queryEntity(ctx, r.db, qb, v, scanEntity[T])
+}
+
+// Delete deletes the vertex whose ID is id. It fails if the vertex has edges.
+func (r *Repository[T]) Delete(ctx context.Context, id ag.GraphId) error {
+	qb := cypher.New().Match(r.node()).Where(idEq("n", id)).Delete("n")
+	return exec(ctx, r.db, qb)
+}
+
+// DetachDelete deletes the vertex whose ID is id and all its edges.
+func (r *Repository[T]) DetachDelete(ctx context.Context, id ag.GraphId) error {
+	qb := cypher.New().Match(r.node()).Where(idEq("n", id)).DetachDelete("n")
+	return exec(ctx, r.db, qb)
+}
+
+// assignments returns SET items of variable for props in the order of keys.
+func assignments(variable string, props cypher.Props) []cypher.SetItem {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	items := make([]cypher.SetItem, len(keys))
+	for i, k := range keys {
+		items[i] = cypher.Assign(cypher.Prop(variable, k), props[k])
+	}
+	return items
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ogm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/bitnine-oss/agensgraph-golang"
+	"github.com/bitnine-oss/agensgraph-golang/cypher"
+)
+
+type person struct {
+	ag.TrackedVertexHeader `label:"person"`
+	Name                   string `ag:"name"`
+	Age                    int    `ag:"age,omitempty"`
+}
+
+func (p *person) Scan(src interface{}) error {
+	return ag.ScanEntity(src, p)
+}
+
+func checkQueries(t *testing.T, d *fakeDriver, want ...string) {
+	t.Helper()
+	if len(d.queries) != len(want) {
+		t.Fatalf("got %v, want %q", d.queries, want)
+	}
+	for i, q := range d.queries {
+		if q.String() != want[i] {
+			t.Errorf("got %s, want %s", q, want[i])
+		}
+	}
+}
+
+func TestRepositoryCreate(t *testing.T) {
+	db, d := newFakeDB([]string{`person[3.1]{"name": "alice", "age": 30}`})
+	defer db.Close()
+
+	people, err := NewRepository[person](db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &person{Name: "alice", Age: 30}
+	err = people.Create(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Valid || p.Id.String() != "3.1" || p.Label != "person" {
+		t.Errorf("got %+v", p)
+	}
+
+	checkQueries(t, d, `CREATE (n:person {age: $1::jsonb, name: $2::jsonb}) RETURN n ["30" "\"alice\""]`)
+}
+
+type untaggedPerson struct {
+	ag.VertexHeader `label:"person"`
+	Name            string `json:"name"`
+}
+
+func (p *untaggedPerson) Scan(src interface{}) error {
+	return ag.ScanEntity(src, p)
+}
+
+func TestRepositoryCreateUntagged(t *testing.T) {
+	db, d := newFakeDB([]string{`person[3.1]{"name": "alice"}`})
+	defer db.Close()
+
+	people, err := NewRepository[untaggedPerson](db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &untaggedPerson{Name: "alice"}
+	err = people.Create(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Valid || p.Id.String() != "3.1" || p.Name != "alice" {
+		t.Errorf("got %+v", p)
+	}
+
+	checkQueries(t, d, `CREATE (n:person {name: $1::jsonb}) RETURN n ["\"alice\""]`)
+}
+
+func TestRepositoryFind(t *testing.T) {
+	db, d := newFakeDB(
+		[]string{`person[3.1]{"name": "alice"}`},
+		nil,
+		[]string{`person[3.1]{"name": "alice"}`, `person[3.2]{"name": "alice", "age": 7}`},
+	)
+	defer db.Close()
+
+	people, err := NewRepository[person](db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	id, _ := ag.NewGraphId("3.1")
+
+	p, err := people.FindByID(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "alice" || !p.Id.Equal(id) {
+		t.Errorf("got %+v", p)
+	}
+
+	_, err = people.FindByID(ctx, id)
+	if err != sql.ErrNoRows {
+		t.Errorf("got %v, want %v", err, sql.ErrNoRows)
+	}
+
+	ps, err := people.FindBy(ctx, cypher.Props{"name": "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 2 || ps[1].Age != 7 {
+		t.Errorf("got %+v", ps)
+	}
+
+	checkQueries(t, d,
+		`MATCH (n:person) WHERE id(n) = $1 RETURN n ["3.1"]`,
+		`MATCH (n:person) WHERE id(n) = $1 RETURN n ["3.1"]`,
+		`MATCH (n:person {name: $1::jsonb}) RETURN n ORDER BY id(n) ["\"alice\""]`,
+	)
+}
+
+func TestRepositoryUpdateDelete(t *testing.T) {
+	db, d := newFakeDB(
+		[]string{`person[3.1]{"name": "bob", "age": 31}`},
+		[]string{`person[3.1]{"name": "carol", "age": 31}`},
+	)
+	defer db.Close()
+
+	people, err := NewRepository[person](db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var p person
+	err = people.Update(ctx, &p)
+	if err == nil {
+		t.Error("error expected for NULL vertex")
+	}
+
+	err = p.Scan([]byte(`person[3.1]{"name": "bob"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Age = 31
+	err = people.Update(ctx, &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Age != 31 || p.Name != "bob" {
+		t.Errorf("got %+v", p)
+	}
+
+	// all the properties are set if the vertex has not been scanned
+	q := person{Name: "carol"}
+	q.Valid, q.Id = true, p.Id
+	err = people.Update(ctx, &q)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = people.Delete(ctx, p.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = people.DetachDelete(ctx, p.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkQueries(t, d,
+		`MATCH (n:person) WHERE id(n) = $1 SET n.age = $2::jsonb RETURN n ["3.1" "31"]`,
+		`MATCH (n:person) WHERE id(n) = $1 SET n.name = $2::jsonb RETURN n ["3.1" "\"carol\""]`,
+		`MATCH (n:person) WHERE id(n) = $1 DELETE n ["3.1"]`,
+		`MATCH (n:person) WHERE id(n) = $1 DETACH DELETE n ["3.1"]`,
+	)
+}
+
+// a Repository not made by NewRepository reports an error instead of panicking
+func TestRepositoryInvalidType(t *testing.T) {
+	db, _ := newFakeDB([]string{`person[3.1]{}`})
+	defer db.Close()
+
+	ctx := context.Background()
+	r := &Repository[ambiguousPerson]{db: db, label: "person"}
+	err := r.Update(ctx, &ambiguousPerson{})
+	if err == nil {
+		t.Error("error expected for Update")
+	}
+	_, err = r.FindByID(ctx, ag.GraphId{})
+	if err == nil {
+		t.Error("error expected for FindByID")
+	}
+}
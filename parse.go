@@ -0,0 +1,271 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// The functions in this file scan the text representation of graphid, vertex
+// and edge in a single pass without regular expressions, and write labels in
+// the same representation.
+
+// countDigits returns the number of leading ASCII digits of s.
+func countDigits[T string | []byte](s T) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// matchGraphId returns the length of the leading `\d+\.\d+` of b, or 0 if b
+// does not start with it.
+func matchGraphId(b []byte) int {
+	n := countDigits(b)
+	if n < 1 || n+1 >= len(b) || b[n] != byte('.') {
+		return 0
+	}
+	m := countDigits(b[n+1:])
+	if m < 1 {
+		return 0
+	}
+	return n + 1 + m
+}
+
+// parseDecimal parses s which consists of ASCII digits only. ok is false if
+// the result does not fit in bitSize bits.
+func parseDecimal[T string | []byte](s T, bitSize uint) (u uint64, ok bool) {
+	max := uint64(1)<<bitSize - 1
+	for i := 0; i < len(s); i++ {
+		d := uint64(s[i] - '0')
+		if u > (max-d)/10 {
+			return 0, false
+		}
+		u = u*10 + d
+	}
+	return u, true
+}
+
+// parseGraphId parses s whose format is `^(\d+)\.(\d+)$` and returns it in the
+// layout of graphid.
+func parseGraphId[T string | []byte](s T) (uint64, error) {
+	n := countDigits(s)
+	if n < 1 || n+1 >= len(s) || s[n] != '.' || countDigits(s[n+1:]) != len(s)-n-1 {
+		return 0, fmt.Errorf("bad graphid representation: %q", s)
+	}
+
+	labelId, ok := parseDecimal(s[:n], labelBit)
+	if !ok {
+		// strconv gives the error message
+		_, err := strconv.ParseUint(string(s[:n]), 10, labelBit)
+		return 0, errors.New("invalid label ID: " + err.Error())
+	}
+	if labelId == 0 {
+		return 0, fmt.Errorf("invalid label ID: %d", labelId)
+	}
+
+	localId, ok := parseDecimal(s[n+1:], localBit)
+	if !ok {
+		_, err := strconv.ParseUint(string(s[n+1:]), 10, localBit)
+		return 0, errors.New("invalid local ID: " + err.Error())
+	}
+	if localId == 0 {
+		return 0, fmt.Errorf("invalid local ID: %d", localId)
+	}
+
+	return makeGraphIdUint64(uint16(labelId), localId), nil
+}
+
+// scanLabel scans a label at the beginning of b and calls match for the rest
+// of the core starting at '['. match returns the length of the rest, or 0 if it
+// does not match. scanLabel returns the label, the offset of the rest and the
+// length of the whole core. advance is 0 if no match is found.
+//
+// A label may be a double-quoted identifier in which "" stands for ". If b
+// starts with " but it is not a valid quoted label, the label is scanned as an
+// unquoted one.
+func scanLabel(b []byte, match func(b []byte) int) (label []byte, rest int, advance int) {
+	if len(b) > 0 && b[0] == byte('"') {
+		label, rest = scanQuotedLabel(b)
+		if rest > 0 {
+			if n := match(b[rest:]); n > 0 {
+				return label, rest, rest + n
+			}
+		}
+	}
+
+	// the shortest label followed by a match; `^(.+?)` + match
+	for i := 0; i < len(b); i++ {
+		switch b[i] {
+		case byte('\n'):
+			return nil, 0, 0
+		case byte('['):
+			if i < 1 {
+				continue
+			}
+			if n := match(b[i:]); n > 0 {
+				return b[:i], i, i + n
+			}
+		}
+	}
+	return nil, 0, 0
+}
+
+// scanQuotedLabel scans a non-empty double-quoted label at the beginning of b
+// and returns the unquoted label and the length of the quoted one. It returns
+// 0 if there is no valid quoted label.
+func scanQuotedLabel(b []byte) (label []byte, advance int) {
+	escaped := false
+	for i := 1; i < len(b); i++ {
+		if b[i] != byte('"') {
+			continue
+		}
+		if i+1 < len(b) && b[i+1] == byte('"') {
+			escaped = true
+			i++
+			continue
+		}
+
+		if i < 2 {
+			return nil, 0
+		}
+		label = b[1:i]
+		if escaped {
+			label = bytes.ReplaceAll(label, []byte(`""`), []byte(`"`))
+		}
+		return label, i + 1
+	}
+	return nil, 0
+}
+
+// appendLabel appends label to b. label is double-quoted unless it consists of
+// ASCII letters, digits, underscores and non-ASCII characters only, and does
+// not start with a digit.
+func appendLabel(b []byte, label string) []byte {
+	if !needsQuote(label) {
+		return append(b, label...)
+	}
+
+	b = append(b, '"')
+	for i := 0; i < len(label); i++ {
+		if label[i] == '"' {
+			b = append(b, '"')
+		}
+		b = append(b, label[i])
+	}
+	return append(b, '"')
+}
+
+func needsQuote(label string) bool {
+	if label == "" || (label[0] >= '0' && label[0] <= '9') {
+		return true
+	}
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '_', c >= utf8.RuneSelf:
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// quoteLabel returns label as it is written in the text representation.
+func quoteLabel(label string) string {
+	if !needsQuote(label) {
+		return label
+	}
+	return string(appendLabel(nil, label))
+}
+
+// matchBracketedGraphId returns the length of the leading `\[\d+\.\d+\]` of
+// b, or 0 if b does not start with it.
+func matchBracketedGraphId(b []byte) int {
+	if len(b) < 1 || b[0] != byte('[') {
+		return 0
+	}
+	n := matchGraphId(b[1:])
+	if n < 1 || n+1 >= len(b) || b[n+1] != byte(']') {
+		return 0
+	}
+	return n + 2
+}
+
+// scanVertexCore matches `^(.+?)\[(\d+\.\d+)\]` against b.
+func scanVertexCore(b []byte) (label, id []byte, advance int) {
+	label, l, advance := scanLabel(b, matchBracketedGraphId)
+	if advance < 1 {
+		return nil, nil, 0
+	}
+	return label, b[l+1 : advance-1], advance
+}
+
+// matchEdgeIds returns the length of the leading
+// `\[\d+\.\d+\]\[\d+\.\d+,\d+\.\d+\]` of b, or 0 if b does not start with it.
+func matchEdgeIds(b []byte) int {
+	n := matchBracketedGraphId(b)
+	if n < 1 || n >= len(b) || b[n] != byte('[') {
+		return 0
+	}
+
+	m := matchGraphId(b[n+1:])
+	if m < 1 {
+		return 0
+	}
+	i := n + 1 + m
+	if i >= len(b) || b[i] != byte(',') {
+		return 0
+	}
+
+	m = matchGraphId(b[i+1:])
+	if m < 1 {
+		return 0
+	}
+	i += 1 + m
+	if i >= len(b) || b[i] != byte(']') {
+		return 0
+	}
+
+	return i + 1
+}
+
+// scanEdgeCore matches `^(.+?)\[(\d+\.\d+)\]\[(\d+\.\d+),(\d+\.\d+)\]` against
+// b.
+func scanEdgeCore(b []byte) (label, id, start, end []byte, advance int) {
+	label, l, advance := scanLabel(b, matchEdgeIds)
+	if advance < 1 {
+		return nil, nil, nil, nil, 0
+	}
+
+	ids := b[l:advance]
+	n := matchBracketedGraphId(ids)
+	id = ids[1 : n-1]
+
+	ids = ids[n+1 : len(ids)-1]
+	m := matchGraphId(ids)
+	start, end = ids[:m], ids[m+1:]
+
+	return label, id, start, end, advance
+}
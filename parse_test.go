@@ -0,0 +1,495 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The regexp based parser below is the previous implementation. It is kept
+// to check that the hand-written parser behaves the same and to compare their
+// performance.
+
+var (
+	regexpGraphId    = regexp.MustCompile(`^(\d+)\.(\d+)$`)
+	regexpVertexCore = regexp.MustCompile(`^(.+?)\[(\d+\.\d+)\]`)
+	regexpEdgeCore   = regexp.MustCompile(`^(.+?)\[(\d+\.\d+)\]\[(\d+\.\d+),(\d+\.\d+)\]`)
+)
+
+func regexpParseGraphId(str string) error {
+	m := regexpGraphId.FindStringSubmatch(str)
+	if m == nil {
+		return fmt.Errorf("bad graphid representation: %q", str)
+	}
+
+	i, err := strconv.ParseUint(m[1], 10, labelBit)
+	if err != nil {
+		return errors.New("invalid label ID: " + err.Error())
+	}
+	if i == 0 {
+		return fmt.Errorf("invalid label ID: %d", i)
+	}
+
+	i, err = strconv.ParseUint(m[2], 10, localBit)
+	if err != nil {
+		return errors.New("invalid local ID: " + err.Error())
+	}
+	if i == 0 {
+		return fmt.Errorf("invalid local ID: %d", i)
+	}
+
+	return nil
+}
+
+func regexpScanGraphId(b []byte) (GraphId, error) {
+	err := regexpParseGraphId(string(b))
+	if err != nil {
+		return GraphId{}, err
+	}
+	return GraphId{Valid: true, b: append([]byte(nil), b...)}, nil
+}
+
+func regexpReadVertexElement(b []byte) (advance int, data *entityData, err error) {
+	if bytes.HasPrefix(b, nullElementValue) {
+		advance = len(nullElementValue)
+		return
+	}
+
+	m := regexpVertexCore.FindSubmatch(b)
+	if m == nil {
+		err = fmt.Errorf("bad vertex representation: %s", b)
+		return
+	}
+	advance = len(m[0])
+
+	props, err := readJSONObject(b[advance:])
+	if err != nil {
+		err = errors.New("invalid vertex properties: " + err.Error())
+		return
+	}
+	advance += len(props)
+
+	var c VertexCore
+	c.Label = string(m[1])
+	c.Id, err = regexpScanGraphId(m[2])
+	if err != nil {
+		err = errors.New("invalid vertex ID: " + err.Error())
+		return
+	}
+
+	data = &entityData{c, props}
+	return
+}
+
+func regexpReadEdgeElement(b []byte) (advance int, data *entityData, err error) {
+	if bytes.HasPrefix(b, nullElementValue) {
+		advance = len(nullElementValue)
+		return
+	}
+
+	m := regexpEdgeCore.FindSubmatch(b)
+	if m == nil {
+		err = fmt.Errorf("bad edge representation: %s", b)
+		return
+	}
+	advance = len(m[0])
+
+	props, err := readJSONObject(b[advance:])
+	if err != nil {
+		err = errors.New("invalid edge properties: " + err.Error())
+		return
+	}
+	advance += len(props)
+
+	var c EdgeCore
+	c.Label = string(m[1])
+	c.Id, err = regexpScanGraphId(m[2])
+	if err != nil {
+		err = errors.New("invalid edge ID: " + err.Error())
+		return
+	}
+	c.Start, err = regexpScanGraphId(m[3])
+	if err != nil {
+		err = errors.New("invalid edge start ID: " + err.Error())
+		return
+	}
+	c.End, err = regexpScanGraphId(m[4])
+	if err != nil {
+		err = errors.New("invalid edge end ID: " + err.Error())
+		return
+	}
+
+	data = &entityData{c, props}
+	return
+}
+
+func regexpReadElements(b []byte, read readElementFunc) ([]interface{}, error) {
+	b = b[1 : len(b)-1]
+
+	var ds []interface{}
+	for len(b) > 0 {
+		if len(ds) > 0 {
+			b = b[1:]
+		}
+
+		advance, data, err := read(b)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			ds = append(ds, nil)
+		} else {
+			ds = append(ds, data)
+		}
+
+		b = b[advance:]
+	}
+
+	return ds, nil
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	return err.Error()
+}
+
+func TestParseGraphId(t *testing.T) {
+	tests := []string{
+		"",
+		".",
+		"1.",
+		".1",
+		"1.1",
+		"01.001",
+		"1.1.1",
+		"a.1",
+		"1.1a",
+		" 1.1",
+		"0.1",
+		"1.0",
+		"65535.281474976710655",
+		"65536.1",
+		"1.281474976710656",
+		"99999999999999999999.1",
+		"1.99999999999999999999",
+	}
+	for _, s := range tests {
+		_, err := parseGraphId(s)
+		want := regexpParseGraphId(s)
+		if e, w := errorString(err), errorString(want); e != w {
+			t.Errorf("got %q for %q, want %q", e, s, w)
+		}
+	}
+}
+
+var parseElementTests = []string{
+	``,
+	`v`,
+	`NULL`,
+	`v[3.1]{}`,
+	`v[3.1]`,
+	`v[3.1]{"a": "}"}`,
+	`[3.1]{}`,
+	`v[]{}`,
+	`v[3.]{}`,
+	`v[.1]{}`,
+	`v[0.1]{}`,
+	`v[3.0]{}`,
+	`v[65536.1]{}`,
+	`v[x][3.1]{}`,
+	`v[3.1][3.2]{}`,
+	"v\n[3.1]{}",
+	`라벨[3.1]{}`,
+	`e[4.1][3.1,3.2]{}`,
+	`e[4.1][3.1,3.2]`,
+	`e[4.1][3.1]{}`,
+	`e[4.1][3.1,]{}`,
+	`e[4.1][3.1,3.2{}`,
+	`e[4.1][0.1,3.2]{}`,
+	`e[4.1][3.1,3.0]{}`,
+	`e[4.1]x[4.2][3.1,3.2]{}`,
+	`e[x][4.2][3.1,3.2]{"x": [1, {"y": null}]},v[3.1]{}`,
+}
+
+func TestParseVertexElement(t *testing.T) {
+	for _, s := range parseElementTests {
+		b := []byte(s)
+		n, d, err := readVertexElement(b)
+		wn, wd, werr := regexpReadVertexElement(b)
+		if e, w := errorString(err), errorString(werr); e != w {
+			t.Errorf("got %q for %q, want %q", e, s, w)
+			continue
+		}
+		if n != wn {
+			t.Errorf("got advance %d for %q, want %d", n, s, wn)
+		}
+		if (d == nil) != (wd == nil) {
+			t.Errorf("got %v for %q, want %v", d, s, wd)
+		} else if d != nil {
+			c, wc := d.core.(VertexCore), wd.core.(VertexCore)
+			if c.Label != wc.Label || c.Id.String() != wc.Id.String() || !bytes.Equal(d.properties, wd.properties) {
+				t.Errorf("got %v for %q, want %v", d, s, wd)
+			}
+		}
+	}
+}
+
+func TestParseEdgeElement(t *testing.T) {
+	for _, s := range parseElementTests {
+		b := []byte(s)
+		n, d, err := readEdgeElement(b)
+		wn, wd, werr := regexpReadEdgeElement(b)
+		if e, w := errorString(err), errorString(werr); e != w {
+			t.Errorf("got %q for %q, want %q", e, s, w)
+			continue
+		}
+		if n != wn {
+			t.Errorf("got advance %d for %q, want %d", n, s, wn)
+		}
+		if (d == nil) != (wd == nil) {
+			t.Errorf("got %v for %q, want %v", d, s, wd)
+		} else if d != nil {
+			c, wc := d.core.(EdgeCore), wd.core.(EdgeCore)
+			if c.Label != wc.Label || fmt.Sprint(c.Id, c.Start, c.End) != fmt.Sprint(wc.Id, wc.Start, wc.End) || !bytes.Equal(d.properties, wd.properties) {
+				t.Errorf("got %v for %q, want %v", d, s, wd)
+			}
+		}
+	}
+}
+
+func TestParseQuotedLabel(t *testing.T) {
+	tests := []struct {
+		src   string
+		label string
+	}{
+		{`"a[b"[3.1]{}`, "a[b"},
+		{`"a""b"[3.1]{}`, `a"b`},
+		{`"a]"[3.1]{}`, "a]"},
+		{`"사람"[3.1]{}`, "사람"},
+		{`사람[3.1]{}`, "사람"},
+		{`"a,b"[3.1]{}`, "a,b"},
+		{`""[3.1]{}`, `""`},
+		{`"a[3.1]{}`, `"a`},
+	}
+	for _, c := range tests {
+		var v BasicVertex
+		err := v.Scan([]byte(c.src))
+		if err != nil {
+			t.Errorf("%s: %s", c.src, err)
+			continue
+		}
+		if v.Label != c.label {
+			t.Errorf("got %q, want %q", v.Label, c.label)
+		}
+	}
+
+	var e BasicEdge
+	err := e.Scan([]byte(`"e""[4.1]"[4.1][3.1,3.2]{}`))
+	if err != nil {
+		t.Error(err)
+	} else if want := `e"[4.1]`; e.Label != want {
+		t.Errorf("got %q, want %q", e.Label, want)
+	}
+}
+
+func TestAppendLabel(t *testing.T) {
+	tests := []struct {
+		label string
+		text  string
+	}{
+		{"person", "person"},
+		{"Person_2", "Person_2"},
+		{"사람", "사람"},
+		{"2nd", `"2nd"`},
+		{"a b", `"a b"`},
+		{"a[b", `"a[b"`},
+		{`a"b`, `"a""b"`},
+		{"", `""`},
+	}
+	for _, c := range tests {
+		if s := string(appendLabel(nil, c.label)); s != c.text {
+			t.Errorf("got %s, want %s", s, c.text)
+		}
+		if s := quoteLabel(c.label); s != c.text {
+			t.Errorf("got %s, want %s", s, c.text)
+		}
+	}
+
+	// round trip
+	for _, label := range []string{"a[b", `a"b`, "사람", "x,y]"} {
+		var v BasicVertex
+		v.Valid, v.Label, v.Id = true, label, mustNewGraphId("3.1")
+		val, err := v.Value()
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		var w BasicVertex
+		err = w.Scan(val)
+		if err != nil {
+			t.Error(err)
+		} else if w.Label != label {
+			t.Errorf("got %q, want %q", w.Label, label)
+		} else if w.String() != v.String() {
+			t.Errorf("got %s, want %s", w, v)
+		}
+	}
+}
+
+func TestParsePathError(t *testing.T) {
+	tests := []string{
+		`[`,
+		`[v[3.1]{}`,
+		`[v[3.1]{},`,
+		`[v[3.1]{},v[3.2]{}]`,
+		`[v[3.1]{},e[4.1][3.1,3.2]{},x]`,
+	}
+	for _, s := range tests {
+		var p BasicPath
+		err := p.Scan([]byte(s))
+		if err == nil {
+			t.Errorf("error expected for %s", s)
+		}
+	}
+}
+
+func benchmarkVertexArray(n int) []byte {
+	s := make([]string, n)
+	for i := range s {
+		s[i] = fmt.Sprintf(`person[3.%d]{"name": "person %d", "age": %d, "tags": ["a", "b"]}`, i+1, i, i%100)
+	}
+	return []byte("[" + strings.Join(s, ",") + "]")
+}
+
+func benchmarkEdgeArray(n int) []byte {
+	s := make([]string, n)
+	for i := range s {
+		s[i] = fmt.Sprintf(`knows[4.%d][3.%d,3.%d]{"since": %d}`, i+1, i+1, i+2, 1970+i%50)
+	}
+	return []byte("[" + strings.Join(s, ",") + "]")
+}
+
+func BenchmarkReadVertexElements(b *testing.B) {
+	src := benchmarkVertexArray(1000)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := readVertexElements(src)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadVertexElementsRegexp(b *testing.B) {
+	src := benchmarkVertexArray(1000)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := regexpReadElements(src, regexpReadVertexElement)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadEdgeElements(b *testing.B) {
+	src := benchmarkEdgeArray(1000)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := readEdgeElements(src)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadEdgeElementsRegexp(b *testing.B) {
+	src := benchmarkEdgeArray(1000)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := regexpReadElements(src, regexpReadEdgeElement)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseGraphId(b *testing.B) {
+	src := []byte("65535.281474976710655")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := parseGraphId(src)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseGraphIdRegexp(b *testing.B) {
+	src := []byte("65535.281474976710655")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err := regexpParseGraphId(string(src))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBasicVertexArrayScan(b *testing.B) {
+	src := benchmarkVertexArray(1000)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var vs []BasicVertex
+		err := Array(&vs).Scan(src)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBasicPathScan(b *testing.B) {
+	s := make([]string, 0, 201)
+	for i := 0; i < 100; i++ {
+		s = append(s, fmt.Sprintf(`v[3.%d]{}`, i+1), fmt.Sprintf(`e[4.%d][3.%d,3.%d]{}`, i+1, i+1, i+2))
+	}
+	s = append(s, `v[3.101]{}`)
+	src := []byte("[" + strings.Join(s, ",") + "]")
+
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var p BasicPath
+		err := p.Scan(src)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// UnmarshalProperties stores properties b of an entity in v which is a
+// pointer to a struct. This is the default behavior of ScanEntity for an entity
+// that does not implement PropertiesSaver.
+//
+// If none of the fields of the struct has the "ag" key in its tag,
+// UnmarshalProperties calls json.Unmarshal over v. Otherwise, only the fields
+// that have the key are stored. Fields of an embedded struct that does not have
+// the key are also looked up. The format of the tag value is as follows.
+//
+//	Field T `ag:"name"`               // property "name"
+//	Field T `ag:"address.city"`       // property "city" of object "address"
+//	Field T `ag:"name,required"`      // an error if "name" is missing or null
+//	Field T `ag:"age,string"`         // "age" may be a JSON string like "42"
+//	                                  // (no effect on string fields)
+//	Field T `ag:"name,omitempty"`     // omit the zero value on MarshalProperties
+//	Field T `ag:"-"`                  // ignored
+//	Field M `ag:",rest"`              // all the other properties
+//
+// If the name is empty, the name of the field is used. A field tagged with
+// "rest" must be a map with string keys, and it receives the properties whose
+// top-level name is not used by any other field. Fields without "required" are
+// optional and set to the zero value if the property is missing.
+func UnmarshalProperties(b []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return json.Unmarshal(b, v)
+	}
+
+	m, err := propertyMappingOf(rv.Type().Elem())
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return json.Unmarshal(b, v)
+	}
+
+	return m.unmarshal(b, rv.Elem())
+}
+
+// MarshalProperties returns properties of an entity from v. This is the
+// default behavior of ValueEntity for an entity that does not implement
+// PropertiesLoader.
+//
+// It uses the same "ag" tags as UnmarshalProperties. If v does not use the
+// tags, MarshalProperties calls json.Marshal over v.
+func MarshalProperties(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return json.Marshal(v)
+	}
+
+	m, err := propertyMappingOf(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return json.Marshal(v)
+	}
+
+	return m.marshal(rv)
+}
+
+type propertyField struct {
+	index     []int
+	path      []string
+	required  bool
+	asString  bool
+	omitEmpty bool
+}
+
+func (f *propertyField) name() string {
+	return strings.Join(f.path, ".")
+}
+
+type propertyMapping struct {
+	fields []propertyField
+	rest   []int           // index of the field tagged with "rest"
+	keys   map[string]bool // top-level names used by fields
+}
+
+type propertyMappingEntry struct {
+	m   *propertyMapping
+	err error
+}
+
+// map[reflect.Type]propertyMappingEntry
+var propertyMappings sync.Map
+
+var typeRawMessage = reflect.TypeOf(json.RawMessage(nil))
+
+func propertyMappingOf(t reflect.Type) (*propertyMapping, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	if e, ok := propertyMappings.Load(t); ok {
+		e := e.(propertyMappingEntry)
+		return e.m, e.err
+	}
+
+	m := &propertyMapping{keys: map[string]bool{}}
+	err := m.addFields(t, nil)
+	if err == nil {
+		err = m.validate()
+	}
+	if err != nil {
+		err = fmt.Errorf("invalid ag tag of %s: %s", t, err)
+		m = nil
+	} else if len(m.fields) < 1 && m.rest == nil {
+		m = nil
+	}
+
+	propertyMappings.Store(t, propertyMappingEntry{m, err})
+	return m, err
+}
+
+func (m *propertyMapping) addFields(t reflect.Type, index []int) error {
+	for i, n := 0, t.NumField(); i < n; i++ {
+		f := t.Field(i)
+		fi := append(append([]int(nil), index...), i)
+
+		tag, ok := f.Tag.Lookup("ag")
+		if !ok {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				err := m.addFields(f.Type, fi)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if tag == "-" {
+			continue
+		}
+		if f.PkgPath != "" {
+			return fmt.Errorf("unexported field %s", f.Name)
+		}
+
+		opts := strings.Split(tag, ",")
+		pf := propertyField{index: fi}
+		rest := false
+		for _, opt := range opts[1:] {
+			switch opt {
+			case "required":
+				pf.required = true
+			case "optional":
+				pf.required = false
+			case "string":
+				// a string field is already a JSON string
+				ft := f.Type
+				for ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				pf.asString = ft.Kind() != reflect.String
+			case "omitempty":
+				pf.omitEmpty = true
+			case "rest":
+				rest = true
+			default:
+				return fmt.Errorf("unknown option %q of field %s", opt, f.Name)
+			}
+		}
+
+		if rest {
+			if m.rest != nil {
+				return fmt.Errorf("duplicate rest field %s", f.Name)
+			}
+			if f.Type.Kind() != reflect.Map || f.Type.Key().Kind() != reflect.String {
+				return fmt.Errorf("rest field %s is not a map with string keys", f.Name)
+			}
+			m.rest = fi
+			continue
+		}
+
+		name := opts[0]
+		if name == "" {
+			name = f.Name
+		}
+		pf.path = strings.Split(name, ".")
+		for _, p := range pf.path {
+			if p == "" {
+				return fmt.Errorf("bad property path %q of field %s", name, f.Name)
+			}
+		}
+
+		m.fields = append(m.fields, pf)
+		m.keys[pf.path[0]] = true
+	}
+
+	return nil
+}
+
+// validate reports an error if a property path is a prefix of another.
+func (m *propertyMapping) validate() error {
+	for i := range m.fields {
+		for j := i + 1; j < len(m.fields); j++ {
+			a, b := m.fields[i].path, m.fields[j].path
+			if len(a) > len(b) {
+				a, b = b, a
+			}
+			prefix := true
+			for k := range a {
+				if a[k] != b[k] {
+					prefix = false
+					break
+				}
+			}
+			if prefix {
+				return fmt.Errorf("conflicting properties %q and %q", m.fields[i].name(), m.fields[j].name())
+			}
+		}
+	}
+	return nil
+}
+
+func (m *propertyMapping) unmarshal(b []byte, rv reflect.Value) error {
+	var obj map[string]json.RawMessage
+	err := json.Unmarshal(b, &obj)
+	if err != nil {
+		return err
+	}
+
+	for i := range m.fields {
+		f := &m.fields[i]
+
+		fv := rv.FieldByIndex(f.index)
+		fv.Set(reflect.Zero(fv.Type()))
+
+		raw, err := lookupProperty(obj, f.path)
+		if err != nil {
+			return fmt.Errorf("invalid property %q: %s", f.name(), err)
+		}
+		if raw == nil {
+			if f.required {
+				return fmt.Errorf("missing required property %q", f.name())
+			}
+			continue
+		}
+
+		if f.asString && len(raw) > 0 && raw[0] == byte('"') {
+			var s string
+			err = json.Unmarshal(raw, &s)
+			if err != nil {
+				return fmt.Errorf("invalid property %q: %s", f.name(), err)
+			}
+			raw = []byte(s)
+		}
+
+		err = json.Unmarshal(raw, fv.Addr().Interface())
+		if err != nil {
+			return fmt.Errorf("invalid property %q: %s", f.name(), err)
+		}
+	}
+
+	if m.rest != nil {
+		fv := rv.FieldByIndex(m.rest)
+		rt := fv.Type()
+		rest := reflect.MakeMap(rt)
+		for k, raw := range obj {
+			if m.keys[k] {
+				continue
+			}
+
+			ev := reflect.New(rt.Elem())
+			if rt.Elem() == typeRawMessage {
+				ev.Elem().SetBytes(append([]byte(nil), raw...))
+			} else {
+				err = json.Unmarshal(raw, ev.Interface())
+				if err != nil {
+					return fmt.Errorf("invalid property %q: %s", k, err)
+				}
+			}
+			rest.SetMapIndex(reflect.ValueOf(k).Convert(rt.Key()), ev.Elem())
+		}
+		fv.Set(rest)
+	}
+
+	return nil
+}
+
+// lookupProperty returns nil if the property at path is missing or null.
+func lookupProperty(obj map[string]json.RawMessage, path []string) (json.RawMessage, error) {
+	n := len(path)
+	for i := 0; i < n-1; i++ {
+		raw, ok := obj[path[i]]
+		if !ok || isJSONNull(raw) {
+			return nil, nil
+		}
+
+		obj = nil
+		err := json.Unmarshal(raw, &obj)
+		if err != nil {
+			return nil, errors.New("not an object: " + strings.Join(path[:i+1], "."))
+		}
+	}
+
+	raw := obj[path[n-1]]
+	if isJSONNull(raw) {
+		return nil, nil
+	}
+	return raw, nil
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	return len(raw) < 1 || string(raw) == "null"
+}
+
+func (m *propertyMapping) marshal(rv reflect.Value) ([]byte, error) {
+	obj := map[string]interface{}{}
+
+	if m.rest != nil {
+		iter := rv.FieldByIndex(m.rest).MapRange()
+		for iter.Next() {
+			obj[iter.Key().String()] = iter.Value().Interface()
+		}
+	}
+
+	for i := range m.fields {
+		f := &m.fields[i]
+
+		fv := rv.FieldByIndex(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		raw, err := json.Marshal(fv.Interface())
+		if err != nil {
+			return nil, fmt.Errorf("invalid property %q: %s", f.name(), err)
+		}
+		if f.asString && raw[0] != byte('"') && string(raw) != "null" {
+			raw, _ = json.Marshal(string(raw))
+		}
+
+		o := obj
+		for _, p := range f.path[:len(f.path)-1] {
+			next := map[string]interface{}{}
+			if prev, ok := o[p].(map[string]interface{}); ok {
+				// copy it not to modify the map of the rest field
+				for k, v := range prev {
+					next[k] = v
+				}
+			}
+			o[p] = next
+			o = next
+		}
+		o[f.path[len(f.path)-1]] = json.RawMessage(raw)
+	}
+
+	return json.Marshal(obj)
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type taggedVertex struct {
+	VertexHeader
+	FullName string                 `ag:"name,required"`
+	City     string                 `ag:"address.city"`
+	Age      int                    `ag:"age,string"`
+	Nick     string                 `ag:",omitempty"`
+	Ignored  string                 `ag:"-"`
+	Rest     map[string]interface{} `ag:",rest"`
+}
+
+func (v *taggedVertex) Scan(src interface{}) error {
+	return ScanEntity(src, v)
+}
+
+// saveEntityData - UnmarshalProperties
+func TestPropertiesTagged(t *testing.T) {
+	b := []byte(`v[3.1]{"name": "go", "address": {"city": "Seoul"}, "age": "9", "x": 1}`)
+	var v taggedVertex
+	v.Nick = "stale"
+	err := v.Scan(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Valid {
+		t.Errorf("got NULL, want Valid %T", v)
+	}
+	if v.FullName != "go" || v.City != "Seoul" || v.Age != 9 {
+		t.Errorf("got %+v", v)
+	}
+	if v.Nick != "" {
+		t.Errorf("got %q, want zero value for missing property", v.Nick)
+	}
+	if len(v.Rest) != 1 || v.Rest["x"] != float64(1) {
+		t.Errorf("got %v, want map[x:1]", v.Rest)
+	}
+}
+
+func TestPropertiesTaggedError(t *testing.T) {
+	tests := [][]byte{
+		[]byte(`v[3.1]{}`),
+		[]byte(`v[3.1]{"name": null}`),
+		[]byte(`v[3.1]{"name": 1}`),
+		[]byte(`v[3.1]{"name": "go", "address": 1}`),
+		[]byte(`v[3.1]{"name": "go", "age": "x"}`),
+	}
+	for _, b := range tests {
+		var v taggedVertex
+		err := v.Scan(b)
+		if err == nil {
+			t.Errorf("error expected for %s", b)
+		}
+	}
+}
+
+// (BasicVertex).Value - MarshalProperties
+func TestPropertiesTaggedValue(t *testing.T) {
+	var v taggedVertex
+	err := v.Scan([]byte(`v[3.1]{"name": "go", "address": {"city": "Seoul", "zip": "1"}, "age": 9}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := ValueEntity(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `v[3.1]{"address":{"city":"Seoul"},"age":"9","name":"go"}`
+	if s := string(val.([]byte)); s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+}
+
+type restRawVertex struct {
+	VertexHeader
+	Rest map[string]json.RawMessage `ag:",rest"`
+}
+
+func TestPropertiesRestRaw(t *testing.T) {
+	b := []byte(`{"a": [1, 2], "b": {"c": null}}`)
+	var v restRawVertex
+	err := UnmarshalProperties(b, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(v.Rest["a"]); s != "[1, 2]" {
+		t.Errorf("got %s, want [1, 2]", s)
+	}
+
+	p, err := MarshalProperties(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a":[1,2],"b":{"c":null}}`; string(p) != want {
+		t.Errorf("got %s, want %s", p, want)
+	}
+}
+
+func TestPropertiesBadTag(t *testing.T) {
+	tests := []interface{}{
+		&struct {
+			A string `ag:"a"`
+			B string `ag:"a.b"`
+		}{},
+		&struct {
+			A string `ag:"a..b"`
+		}{},
+		&struct {
+			A string `ag:"a,unknown"`
+		}{},
+		&struct {
+			A []string `ag:",rest"`
+		}{},
+		&struct {
+			a string `ag:"a"`
+		}{},
+	}
+	for _, v := range tests {
+		err := UnmarshalProperties([]byte(`{}`), v)
+		if err == nil {
+			t.Errorf("error expected for %T", v)
+		}
+	}
+}
+
+type untaggedVertex struct {
+	VertexHeader
+	Name string `json:"name"`
+	Kind string `json:"label"`
+}
+
+// the fields of the header are not properties
+func TestPropertiesUntagged(t *testing.T) {
+	var v untaggedVertex
+	err := ScanEntity([]byte(`v[3.1]{"name": "go", "label": "x", "Valid": false, "id": "9.9"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Valid || v.Label != "v" || v.Id.String() != "3.1" || v.Name != "go" || v.Kind != "x" {
+		t.Errorf("got %+v", v)
+	}
+
+	p, err := MarshalProperties(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"go","label":"x"}`; string(p) != want {
+		t.Errorf("got %s, want %s", p, want)
+	}
+
+	// through another embedded struct
+	w := struct {
+		untaggedVertex
+		Age int
+	}{v, 3}
+	p, err = MarshalProperties(&w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"go","label":"x","Age":3}`; string(p) != want {
+		t.Errorf("got %s, want %s", p, want)
+	}
+
+	var e struct {
+		EdgeHeader
+		W int `json:"w"`
+	}
+	err = ScanEntity([]byte(`e[4.1][3.1,3.2]{"w": 1, "start": "3.9"}`), &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Start.String() != "3.1" || e.W != 1 {
+		t.Errorf("got %+v", e)
+	}
+	p, err = MarshalProperties(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"w":1}`; string(p) != want {
+		t.Errorf("got %s, want %s", p, want)
+	}
+}
+
+func TestPropertiesStringOnString(t *testing.T) {
+	type stringVertex struct {
+		VertexHeader
+		Name string  `ag:"name,string"`
+		Nick *string `ag:"nick,string"`
+	}
+
+	nick := "n"
+	p, err := MarshalProperties(stringVertex{Name: "42", Nick: &nick})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"42","nick":"n"}`; string(p) != want {
+		t.Errorf("got %s, want %s", p, want)
+	}
+
+	var v stringVertex
+	err = UnmarshalProperties(p, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "42" || v.Nick == nil || *v.Nick != "n" {
+		t.Errorf("got %+v", v)
+	}
+}
+
+func TestPropertiesStringNull(t *testing.T) {
+	type nullVertex struct {
+		VertexHeader
+		N *int `ag:"n,string"`
+	}
+
+	p, err := MarshalProperties(nullVertex{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"n":null}`; string(p) != want {
+		t.Errorf("got %s, want %s", p, want)
+	}
+
+	v := nullVertex{N: new(int)}
+	err = UnmarshalProperties(p, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.N != nil {
+		t.Errorf("got %d, want nil", *v.N)
+	}
+}
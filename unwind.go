@@ -0,0 +1,177 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DefaultUnwindChunkSize is the chunk size of ExecUnwind and QueryUnwind if
+// the given one is not positive.
+const DefaultUnwindChunkSize = 1000
+
+// ExecUnwind runs query in the graph of g once for every chunk of rows and
+// returns the sum of the number of rows affected.
+//
+// Each chunk is passed to query as $1, which is a JSON array of the rows
+// encoded with MarshalProperties, so the "ag" tags of structs apply. query
+// usually unwinds it as follows.
+//
+//	UNWIND $1::jsonb AS row CREATE (:person {name: row.name})
+//
+// All the chunks are run in a transaction, so either all the rows or none of
+// them are written.
+func ExecUnwind[R any](ctx context.Context, g *Graph, query string, rows []R, chunkSize int) (int64, error) {
+	tx, err := g.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var n int64
+	err = forEachUnwindChunk(rows, chunkSize, func(chunk string) error {
+		res, err := tx.ExecContext(ctx, query, chunk)
+		if err != nil {
+			return err
+		}
+		// drivers may not support it for Cypher queries
+		if cnt, err := res.RowsAffected(); err == nil {
+			n += cnt
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// QueryUnwind is the same as ExecUnwind except that it returns the results of
+// query in order. query must return one column.
+//
+// The values are scanned with Scan of *T if it implements sql.Scanner,
+// ScanEntity if *T implements Entity, ScanPath if *T implements PathSaver, or
+// Scan of *sql.Rows otherwise.
+//
+//	vs, err := QueryUnwind[BasicVertex](ctx, g,
+//		`UNWIND $1::jsonb AS row CREATE (n:person {name: row.name}) RETURN n`,
+//		people, 0)
+func QueryUnwind[T any, R any](ctx context.Context, g *Graph, query string, rows []R, chunkSize int) ([]T, error) {
+	tx, err := g.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var vals []T
+	err = forEachUnwindChunk(rows, chunkSize, func(chunk string) error {
+		rs, err := tx.QueryContext(ctx, query, chunk)
+		if err != nil {
+			return err
+		}
+		defer rs.Close()
+
+		for rs.Next() {
+			var v T
+			err = scanColumn(rs, &v)
+			if err != nil {
+				return err
+			}
+			vals = append(vals, v)
+		}
+		return rs.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return vals, nil
+}
+
+// forEachUnwindChunk calls fn with every chunk of rows encoded as a JSON
+// array.
+func forEachUnwindChunk[R any](rows []R, chunkSize int, fn func(chunk string) error) error {
+	if chunkSize < 1 {
+		chunkSize = DefaultUnwindChunkSize
+	}
+
+	var b []byte
+	for start := 0; start < len(rows); start += chunkSize {
+		end := min(start+chunkSize, len(rows))
+
+		b = append(b[:0], '[')
+		for i := start; i < end; i++ {
+			if i > start {
+				b = append(b, ',')
+			}
+			r, err := MarshalProperties(rows[i])
+			if err != nil {
+				return fmt.Errorf("row %d: %s", i, err)
+			}
+			b = append(b, r...)
+		}
+		b = append(b, ']')
+
+		// a string is sent as text, which is what jsonb expects
+		err := fn(string(b))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanColumn scans the only column of the current row of rows into dest.
+func scanColumn(rows *sql.Rows, dest interface{}) error {
+	switch d := dest.(type) {
+	case sql.Scanner:
+		return rows.Scan(d)
+	case Entity:
+		var b []byte
+		err := rows.Scan(&b)
+		if err != nil {
+			return err
+		}
+		if b == nil {
+			return ScanEntity(nil, d)
+		}
+		return ScanEntity(b, d)
+	case PathSaver:
+		var b []byte
+		err := rows.Scan(&b)
+		if err != nil {
+			return err
+		}
+		if b == nil {
+			return ScanPath(nil, d)
+		}
+		return ScanPath(b, d)
+	default:
+		return rows.Scan(dest)
+	}
+}
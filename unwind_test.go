@@ -0,0 +1,161 @@
+/*
+Copyright 2018 Bitnine Co., Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ag
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type unwindRow struct {
+	Name string `ag:"name"`
+	Age  int    `ag:"age,omitempty"`
+}
+
+// unwindVertex is an Entity that does not implement sql.Scanner.
+type unwindVertex struct {
+	VertexHeader
+	Name string `ag:"name"`
+}
+
+// newUnwindGraph returns a graph whose queries return a vertex for every
+// element of $1, and the chunks passed to queries.
+func newUnwindGraph() (*Graph, *fakeDriver, *[]string) {
+	d := &fakeDriver{}
+	var chunks []string
+	d.query = func(query string, args []driver.NamedValue) (driver.Rows, error) {
+		chunk := args[0].Value.(string)
+		chunks = append(chunks, chunk)
+
+		var rows []map[string]interface{}
+		err := json.Unmarshal([]byte(chunk), &rows)
+		if err != nil {
+			return nil, err
+		}
+		values := make([][]driver.Value, len(rows))
+		for i, r := range rows {
+			props, _ := json.Marshal(r)
+			values[i] = []driver.Value{[]byte(fmt.Sprintf("v[3.%d]%s", len(chunks)*10+i, props))}
+		}
+		return &fakeRows{[]string{"n"}, values}, nil
+	}
+	d.exec = func(query string, args []driver.NamedValue) error {
+		if len(args) > 0 {
+			chunks = append(chunks, args[0].Value.(string))
+		}
+		return nil
+	}
+	return NewGraph(d, "g"), d, &chunks
+}
+
+func TestQueryUnwind(t *testing.T) {
+	g, d, chunks := newUnwindGraph()
+	defer g.Close()
+
+	rows := []unwindRow{{"a", 1}, {"b", 0}, {"c", 3}}
+	const q = `UNWIND $1::jsonb AS row CREATE (n:v {name: row.name}) RETURN n`
+	vs, err := QueryUnwind[BasicVertex](context.Background(), g, q, rows, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{`[{"name":"a","age":1},{"name":"b"}]`, `[{"age":3,"name":"c"}]`}
+	if len(*chunks) != 2 {
+		t.Fatalf("got %q, want %q", *chunks, want)
+	}
+	for i, c := range *chunks {
+		var got, exp interface{}
+		json.Unmarshal([]byte(c), &got)
+		json.Unmarshal([]byte(want[i]), &exp)
+		if fmt.Sprint(got) != fmt.Sprint(exp) {
+			t.Errorf("got %s, want %s", c, want[i])
+		}
+	}
+
+	if len(vs) != len(rows) {
+		t.Fatalf("got %d vertices, want %d", len(vs), len(rows))
+	}
+	for i, v := range vs {
+		if v.Properties["name"] != rows[i].Name {
+			t.Errorf("got %v, want name %s", v, rows[i].Name)
+		}
+	}
+	if got, want := vs[2].Id.String(), "3.20"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// all the chunks are run in one transaction
+	stmts := d.statements()
+	if n := strings.Count(strings.Join(stmts, "\n"), "BEGIN"); n != 1 {
+		t.Errorf("got %d transactions, want 1: %q", n, stmts)
+	}
+}
+
+func TestQueryUnwindEntity(t *testing.T) {
+	g, _, _ := newUnwindGraph()
+	defer g.Close()
+
+	rows := []map[string]interface{}{{"name": "a"}, {"name": "b"}}
+	vs, err := QueryUnwind[unwindVertex](context.Background(), g, `RETURN`, rows, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 2 || vs[0].Name != "a" || vs[1].Name != "b" || !vs[1].Valid {
+		t.Errorf("got %v", vs)
+	}
+}
+
+func TestExecUnwind(t *testing.T) {
+	g, _, chunks := newUnwindGraph()
+	defer g.Close()
+
+	rows := make([]map[string]int, 5)
+	for i := range rows {
+		rows[i] = map[string]int{"i": i}
+	}
+	_, err := ExecUnwind(context.Background(), g, `UNWIND $1::jsonb AS row CREATE (:v {i: row.i})`, rows, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{`[{"i":0},{"i":1}]`, `[{"i":2},{"i":3}]`, `[{"i":4}]`}
+	if strings.Join(*chunks, " ") != strings.Join(want, " ") {
+		t.Errorf("got %q, want %q", *chunks, want)
+	}
+}
+
+func TestServerQueryUnwind(t *testing.T) {
+	skipUnlessServerTest(t)
+
+	g := mustOpenGraph(t)
+	defer g.Close()
+
+	ctx := context.Background()
+	rows := []unwindRow{{"unwind_a", 1}, {"unwind_b", 2}}
+	vs, err := QueryUnwind[BasicVertex](ctx, g,
+		`UNWIND $1::jsonb AS row CREATE (n:unwind {name: row.name, age: row.age}) RETURN n`, rows, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 2 || vs[0].Label != "unwind" || vs[1].Properties["name"] != "unwind_b" {
+		t.Errorf("got %v", vs)
+	}
+}
@@ -19,7 +19,7 @@ package ag
 import "fmt"
 
 func readJSONObject(b []byte) ([]byte, error) {
-	if b[0] != byte('{') {
+	if len(b) < 1 || b[0] != byte('{') {
 		return nil, fmt.Errorf("invalid JSON object: %s", b)
 	}
 	depth := 1
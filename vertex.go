@@ -22,7 +22,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"regexp"
 )
 
 // Vertex gives any struct an ability to read the value from the database
@@ -35,15 +34,13 @@ type VertexCore struct {
 	Id    GraphId
 }
 
-var vertexCoreRegexp = regexp.MustCompile(`^(.+?)\[(\d+\.\d+)\]`)
-
 func (_ Vertex) readEntity(b []byte) (*entityData, error) {
-	m := vertexCoreRegexp.FindSubmatch(b)
-	if m == nil {
+	label, id, advance := scanVertexCore(b)
+	if advance < 1 {
 		return nil, fmt.Errorf("bad vertex representation: %s", b)
 	}
 
-	return makeVertexData(m[1], m[2], b[len(m[0]):])
+	return makeVertexData(label, id, b[advance:])
 }
 
 func makeVertexData(label, id, props []byte) (*entityData, error) {
@@ -59,35 +56,30 @@ func makeVertexData(label, id, props []byte) (*entityData, error) {
 	return &entityData{c, props}, nil
 }
 
+func appendVertexCore(b []byte, c VertexCore) ([]byte, error) {
+	if c.Label == "" {
+		return nil, errors.New("invalid vertex label: empty")
+	}
+	if !c.Id.Valid {
+		return nil, errors.New("invalid vertex ID: NULL")
+	}
+
+	b = appendLabel(b, c.Label)
+	b = append(b, '[')
+	b = c.Id.appendText(b)
+	return append(b, ']'), nil
+}
+
 func (_ Vertex) readElements(b []byte) ([]interface{}, error) {
 	return readVertexElements(b)
 }
 
-func readVertexElements(b []byte) ([]interface{}, error) {
-	// remove surrounding brackets
-	b = b[1 : len(b)-1]
-
-	var ds []interface{}
-	for len(b) > 0 {
-		if len(ds) > 0 {
-			// remove comma
-			b = b[1:]
-		}
-
-		advance, data, err := readVertexElement(b)
-		if err != nil {
-			return nil, err
-		}
-		if data == nil {
-			ds = append(ds, nil)
-		} else {
-			ds = append(ds, data)
-		}
-
-		b = b[advance:]
-	}
+func (_ Vertex) readElement(b []byte) (int, *entityData, error) {
+	return readVertexElement(b)
+}
 
-	return ds, nil
+func readVertexElements(b []byte) ([]interface{}, error) {
+	return readElements(b, readVertexElement)
 }
 
 func readVertexElement(b []byte) (advance int, data *entityData, err error) {
@@ -96,12 +88,11 @@ func readVertexElement(b []byte) (advance int, data *entityData, err error) {
 		return
 	}
 
-	m := vertexCoreRegexp.FindSubmatch(b)
-	if m == nil {
+	label, id, advance := scanVertexCore(b)
+	if advance < 1 {
 		err = fmt.Errorf("bad vertex representation: %s", b)
 		return
 	}
-	advance = len(m[0])
 
 	props, err := readJSONObject(b[advance:])
 	if err != nil {
@@ -110,16 +101,17 @@ func readVertexElement(b []byte) (advance int, data *entityData, err error) {
 	}
 	advance += len(props)
 
-	data, err = makeVertexData(m[1], m[2], props)
+	data, err = makeVertexData(label, id, props)
 	return
 }
 
 // VertexHeader may be used as an embedded field of any struct to change the
-// struct to an entity for vertex.
+// struct to an entity for vertex. Its fields are not properties, so
+// encoding/json skips them.
 type VertexHeader struct {
 	Vertex
-	Valid      bool // Valid is true if the vertex is not NULL
-	VertexCore      // VertexCore is valid only if Valid is true
+	Valid      bool       `json:"-"` // Valid is true if the vertex is not NULL
+	VertexCore `json:"-"` // VertexCore is valid only if Valid is true
 }
 
 // SaveEntity implements EntitySaver interface.
@@ -139,6 +131,11 @@ func (h *VertexHeader) SaveEntity(valid bool, core interface{}) error {
 	return nil
 }
 
+// LoadEntity implements EntityLoader interface.
+func (h VertexHeader) LoadEntity() (valid bool, core interface{}) {
+	return h.Valid, h.VertexCore
+}
+
 // BasicVertex can be used to scan the value from the database driver as a
 // vertex.
 //
@@ -152,8 +149,8 @@ type BasicVertex struct {
 
 func (v BasicVertex) String() string {
 	if v.Valid {
-		p, _ := json.Marshal(v.Properties)
-		return fmt.Sprintf("%s[%s]%s", v.Label, v.Id, p)
+		p, _ := v.LoadProperties()
+		return fmt.Sprintf("%s[%s]%s", quoteLabel(v.Label), v.Id, p)
 	} else {
 		return "NULL"
 	}
@@ -162,6 +159,7 @@ func (v BasicVertex) String() string {
 // SaveProperties implements PropertiesSaver interface. It calls json.Unmarshal
 // to unmarshal b and store the result in Properties.
 func (v *BasicVertex) SaveProperties(b []byte) error {
+	v.Properties = nil
 	err := json.Unmarshal(b, &v.Properties)
 	if err != nil {
 		return errors.New("invalid vertex properties: " + err.Error())
@@ -169,11 +167,26 @@ func (v *BasicVertex) SaveProperties(b []byte) error {
 	return nil
 }
 
+// LoadProperties implements PropertiesLoader interface. It calls json.Marshal
+// to marshal Properties.
+func (v BasicVertex) LoadProperties() ([]byte, error) {
+	if v.Properties == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(v.Properties)
+}
+
 // Scan implements the database/sql Scanner interface. It calls ScanEntity.
 func (v *BasicVertex) Scan(src interface{}) error {
 	return ScanEntity(src, v)
 }
 
+// Value implements the database/sql/driver Valuer interface. It calls
+// ValueEntity.
+func (v BasicVertex) Value() (driver.Value, error) {
+	return ValueEntity(v)
+}
+
 type basicVertexArray []BasicVertex
 
 func (a *basicVertexArray) Scan(src interface{}) error {
@@ -208,5 +221,59 @@ func (a *basicVertexArray) Scan(src interface{}) error {
 }
 
 func (a basicVertexArray) Value() (driver.Value, error) {
-	return nil, errors.New("Value() on an array of vertex is not supported")
+	if a == nil {
+		return nil, nil
+	}
+
+	return appendEntityArray(nil, len(a), func(i int) EntityLoader {
+		return a[i]
+	})
+}
+
+// TypedVertex can be used to scan the value from the database driver as a
+// vertex whose properties are stored in P.
+//
+// Properties are stored by calling UnmarshalProperties, so P may be a struct
+// that uses "ag" tags. An array of TypedVertex can be scanned by Array.
+type TypedVertex[P any] struct {
+	VertexHeader
+	Properties P
+}
+
+func (v TypedVertex[P]) String() string {
+	if v.Valid {
+		p, _ := MarshalProperties(v.Properties)
+		return fmt.Sprintf("%s[%s]%s", quoteLabel(v.Label), v.Id, p)
+	} else {
+		return "NULL"
+	}
+}
+
+// SaveProperties implements PropertiesSaver interface. It calls
+// UnmarshalProperties to unmarshal b and store the result in Properties.
+func (v *TypedVertex[P]) SaveProperties(b []byte) error {
+	var p P
+	err := UnmarshalProperties(b, &p)
+	if err != nil {
+		return errors.New("invalid vertex properties: " + err.Error())
+	}
+	v.Properties = p
+	return nil
+}
+
+// LoadProperties implements PropertiesLoader interface. It calls
+// MarshalProperties to marshal Properties.
+func (v TypedVertex[P]) LoadProperties() ([]byte, error) {
+	return MarshalProperties(v.Properties)
+}
+
+// Scan implements the database/sql Scanner interface. It calls ScanEntity.
+func (v *TypedVertex[P]) Scan(src interface{}) error {
+	return ScanEntity(src, v)
+}
+
+// Value implements the database/sql/driver Valuer interface. It calls
+// ValueEntity.
+func (v TypedVertex[P]) Value() (driver.Value, error) {
+	return ValueEntity(v)
 }
@@ -16,7 +16,10 @@ limitations under the License.
 
 package ag
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+)
 
 // ScanEntity - case nil
 func TestBasicVertexScanNil(t *testing.T) {
@@ -156,10 +159,131 @@ func TestBasicVertexArrayScan(t *testing.T) {
 }
 
 func TestBasicVertexArrayValue(t *testing.T) {
+	tests := []struct {
+		src []byte
+		val []byte
+	}{
+		{
+			[]byte(`[NULL,v[3.1]{"name": "go"},v[3.2]{}]`),
+			[]byte(`[NULL,v[3.1]{"name":"go"},v[3.2]{}]`),
+		},
+		{
+			[]byte("[]"),
+			[]byte("[]"),
+		},
+	}
+	for _, c := range tests {
+		var vs []BasicVertex
+		err := Array(&vs).Scan(c.src)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		for _, a := range []interface{}{&vs, vs} {
+			val, err := Array(a).Value()
+			if err != nil {
+				t.Error(err)
+			} else if b, ok := val.([]byte); !ok || !bytes.Equal(b, c.val) {
+				t.Errorf("got %s, want %s", val, c.val)
+			}
+		}
+	}
+
 	var vs []BasicVertex
-	_, err := Array(&vs).Value()
+	val, err := Array(vs).Value()
+	if err != nil {
+		t.Error(err)
+	} else if val != nil {
+		t.Errorf("got %s, want nil", val)
+	}
+
+	vs = []BasicVertex{{}, {VertexHeader: VertexHeader{Valid: true}}}
+	_, err = Array(vs).Value()
 	if err == nil {
-		t.Error("error expected for Value() on basicVertexArray")
+		t.Errorf("error expected for %v", vs)
+	}
+}
+
+// (BasicVertex).Value, ValueEntity, (VertexHeader).LoadEntity,
+// (BasicVertex).LoadProperties
+func TestBasicVertexValue(t *testing.T) {
+	var v BasicVertex
+	val, err := v.Value()
+	if err != nil {
+		t.Error(err)
+	} else if val != nil {
+		t.Errorf("got %s, want nil", val)
+	}
+
+	b := []byte(`v[3.1]{"name": "go"}`)
+	err = v.Scan(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, err = v.Value()
+	if err != nil {
+		t.Error(err)
+	} else if want := `v[3.1]{"name":"go"}`; string(val.([]byte)) != want {
+		t.Errorf("got %s, want %s", val, want)
+	}
+
+	v.Properties = nil
+	val, err = v.Value()
+	if err != nil {
+		t.Error(err)
+	} else if want := `v[3.1]{}`; string(val.([]byte)) != want {
+		t.Errorf("got %s, want %s", val, want)
+	}
+
+	v.Label = ""
+	_, err = v.Value()
+	if err == nil {
+		t.Errorf("error expected for empty label")
+	}
+}
+
+func TestUserVertexArrayValue(t *testing.T) {
+	var vs []userVertex
+	err := Array(&vs).Scan([]byte(`[NULL,v[3.1]{"name": "go"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := Array(&vs).Value()
+	if err != nil {
+		t.Error(err)
+	} else if want := `[NULL,v[3.1]{"Name":"go"}]`; string(val.([]byte)) != want {
+		t.Errorf("got %s, want %s", val, want)
+	}
+
+	var nvs []userVertex
+	val, err = Array(&nvs).Value()
+	if err != nil {
+		t.Error(err)
+	} else if val != nil {
+		t.Errorf("got %s, want nil", val)
+	}
+}
+
+type plainVertex struct {
+	VertexHeader
+	Name string `json:"name"`
+}
+
+// ValueEntity - MarshalProperties without "ag" tags
+func TestPlainVertexValue(t *testing.T) {
+	var v plainVertex
+	err := ScanEntity([]byte(`person[3.1]{"name": "a"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val, err := ValueEntity(v)
+	if err != nil {
+		t.Error(err)
+	} else if want := `person[3.1]{"name":"a"}`; string(val.([]byte)) != want {
+		t.Errorf("got %s, want %s", val, want)
 	}
 }
 
@@ -178,10 +302,76 @@ func TestUserVertexArrayScan(t *testing.T) {
 	}
 }
 
+type typedVertexProps struct {
+	Name string `json:"name"`
+}
+
+func TestTypedVertexScan(t *testing.T) {
+	var v TypedVertex[typedVertexProps]
+	err := v.Scan([]byte(`v[3.1]{"name": "go"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Valid {
+		t.Errorf("got NULL, want Valid %T", v)
+	} else if v.Properties.Name != "go" {
+		t.Errorf(`got %q, want "go"`, v.Properties.Name)
+	}
+	if s, want := v.String(), `v[3.1]{"name":"go"}`; s != want {
+		t.Errorf("got %s, want %s", s, want)
+	}
+
+	val, err := v.Value()
+	if err != nil {
+		t.Error(err)
+	} else if want := `v[3.1]{"name":"go"}`; string(val.([]byte)) != want {
+		t.Errorf("got %s, want %s", val, want)
+	}
+
+	err = v.Scan(nil)
+	if err != nil {
+		t.Error(err)
+	} else if s := v.String(); s != "NULL" {
+		t.Errorf("got %s, want NULL", s)
+	}
+
+	var m TypedVertex[map[string]int]
+	err = m.Scan([]byte(`v[3.1]{"n": "x"}`))
+	if err == nil {
+		t.Errorf("error expected for %T", m)
+	}
+}
+
+func TestTypedVertexArray(t *testing.T) {
+	for _, c := range vertexArrayTests {
+		var vs []TypedVertex[typedVertexProps]
+		err := Array(&vs).Scan(c.src)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if n := len(vs); n != c.n {
+			t.Errorf("got len(vs) == %d, want %d", n, c.n)
+		} else if n > 1 && vs[1].Properties.Name != "go" {
+			t.Errorf(`got %q, want "go"`, vs[1].Properties.Name)
+		}
+	}
+
+	vs := []TypedVertex[map[string]int]{{}, {}}
+	_ = vs[1].Scan([]byte(`v[3.1]{}`))
+	val, err := Array(vs).Value()
+	if err != nil {
+		t.Error(err)
+	} else if want := `[NULL,v[3.1]{}]`; string(val.([]byte)) != want {
+		t.Errorf("got %s, want %s", val, want)
+	}
+}
+
 func TestServerVertex(t *testing.T) {
 	skipUnlessServerTest(t)
 
-	db := mustOpenAndSetGraph(t)
+	db := mustOpenGraph(t)
 	defer db.Close()
 
 	_, err := db.Exec(`CREATE (:vv)-[:ve]->(:vv)`)